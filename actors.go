@@ -0,0 +1,109 @@
+package main
+
+import (
+	"awesomeProject/decorate"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+)
+
+// loadActors returns the built-in Doom actors if doomActors is set, patched
+// by the DEHACKED lumps of the loaded WADs and then by the given
+// comma-separated patch files, followed by the actors of the given
+// comma-separated DECORATE or ZScript files, or if there are none, of the
+// DECORATE and ZSCRIPT lumps of the WADs. Later definitions override earlier
+// ones.
+func loadActors(wc *WADCollection, doomActors bool, paths, dehPaths string) (actors []*decorate.Actor, err error) {
+	var patches, patchNames []string
+	var sources, names []string
+	// the collection holds the last loaded WAD first:
+	wc.IterateLumpsBetween("", "", func(lump *Lump) bool {
+		switch lump.Name {
+		case "DEHACKED":
+			patches = append([]string{string(lump.Data)}, patches...)
+			patchNames = append([]string{lump.Name}, patchNames...)
+		case "DECORATE", "ZSCRIPT":
+			if paths == "" {
+				sources = append([]string{string(lump.Data)}, sources...)
+				names = append([]string{lump.Name}, names...)
+			}
+		}
+		return false
+	})
+	if patches, patchNames, err = readSources(patches, patchNames, dehPaths); err != nil {
+		return
+	}
+	if sources, names, err = readSources(sources, names, paths); err != nil {
+		return
+	}
+
+	if doomActors {
+		info := decorate.Vanilla()
+		for i, patch := range patches {
+			if err = info.ApplyDehacked(patch); err != nil {
+				return nil, fmt.Errorf("%s: %w", patchNames[i], err)
+			}
+		}
+		actors = info.Actors()
+	}
+
+	for i, src := range sources {
+		var parsed []*decorate.Actor
+		if parsed, err = decorate.Parse(src); err != nil {
+			return nil, fmt.Errorf("%s: %w", names[i], err)
+		}
+		actors = append(actors, parsed...)
+	}
+	return
+}
+
+// readSources appends the contents of the given comma-separated files to
+// sources and their paths to names.
+func readSources(sources, names []string, paths string) ([]string, []string, error) {
+	if paths == "" {
+		return sources, names, nil
+	}
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, string(b))
+		names = append(names, path)
+	}
+	return sources, names, nil
+}
+
+// saveStateAnimations writes the frames each state of the actor shows, in
+// order, as one animation per state named anim-ACTOR-STATE. States showing
+// frames that were not voxelized are skipped.
+func saveStateAnimations(actor *decorate.Actor, labels []string, frames map[string]voxFrame, meshes map[string]meshFrame, pal color.Palette) {
+	if len(labels) == 0 {
+		labels = actor.Order
+	}
+
+	for _, label := range labels {
+		var seqFrames []voxFrame
+		var seqMeshes []meshFrame
+		for _, s := range actor.Sequence(label) {
+			name := fmt.Sprintf("%s%c", s.Sprite, s.Frame)
+			fr, ok := frames[name]
+			if !ok {
+				continue
+			}
+			seqFrames = append(seqFrames, fr)
+			if m, ok := meshes[name]; ok {
+				seqMeshes = append(seqMeshes, m)
+			}
+		}
+		if len(seqFrames) == 0 {
+			continue
+		}
+		if len(seqMeshes) != len(seqFrames) {
+			seqMeshes = nil
+		}
+		saveAnimation(fmt.Sprintf("%s-%s", actor.Name, label), seqFrames, seqMeshes, pal)
+	}
+}
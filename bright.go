@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parseColorRanges parses a comma-separated list of palette indices and
+// ranges, e.g. "224-231,250", into a set of palette indices.
+func parseColorRanges(s string) (set [256]bool, err error) {
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		var first, last int
+		if first, err = strconv.Atoi(lo); err != nil {
+			return
+		}
+		last = first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil {
+				return
+			}
+		}
+		if first < 0 || last > 255 || first > last {
+			err = fmt.Errorf("bad palette range %q; indices must be 0-255", part)
+			return
+		}
+		for i := first; i <= last; i++ {
+			set[i] = true
+		}
+	}
+	return
+}
+
+// loadBrightmap loads the brightmap of a sprite lump from dir, named after
+// the lump as in GZDoom's brightmaps/sprites folder, e.g. VILEA1.png. It
+// returns nil if the lump has no brightmap.
+func loadBrightmap(dir, lumpName string) (image.Image, error) {
+	for _, name := range []string{lumpName, strings.ToLower(lumpName)} {
+		f, err := os.Open(filepath.Join(dir, name+".png"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		return img, nil
+	}
+	return nil, nil
+}
+
+// isBright reports whether a brightmap lights the patch pixel at (x, y):
+// any pixel that is not black.
+func isBright(bm image.Image, x, y int) bool {
+	if bm == nil {
+		return false
+	}
+	p := image.Point{X: x, Y: y}.Add(bm.Bounds().Min)
+	if !p.In(bm.Bounds()) {
+		return false
+	}
+	r, g, b, a := bm.At(p.X, p.Y).RGBA()
+	return a != 0 && (r|g|b) != 0
+}
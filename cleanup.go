@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CleanupOptions controls the noise removal pass run on a carved volume.
+type CleanupOptions struct {
+	// Connectivity is 6 (faces) or 26 (faces, edges and corners).
+	Connectivity int
+	// MinComponent removes connected components with fewer voxels than this.
+	MinComponent int
+
+	// Open and Close are the kernel radii for morphological opening, which
+	// shaves off spikes, and closing, which fills pits; 0 disables them.
+	Open, Close int
+	// Kernel is the structuring element shape: "cube" or "sphere".
+	Kernel string
+}
+
+func DefaultCleanupOptions() CleanupOptions {
+	return CleanupOptions{
+		Connectivity: 26,
+		Kernel:       "cube",
+	}
+}
+
+// CleanupReport describes what Cleanup removed and added.
+type CleanupReport struct {
+	Components        int
+	RemovedComponents int
+	// RemovedComponentVoxels counts the voxels of the removed components.
+	RemovedComponentVoxels int
+	RemovedByOpen          int
+	AddedByClose           int
+}
+
+// neighborOffsets returns the offsets of the 6- or 26-connected neighborhood.
+func neighborOffsets(connectivity int) (offsets [][3]int, err error) {
+	switch connectivity {
+	case 6:
+		return neighbors6[:], nil
+	case 26:
+		return kernelOffsets(1, "cube")
+	}
+	return nil, fmt.Errorf("connectivity must be 6 or 26, got %d", connectivity)
+}
+
+// kernelOffsets returns the offsets within a structuring element of the given
+// radius, excluding the center, sorted nearest first.
+func kernelOffsets(radius int, shape string) (offsets [][3]int, err error) {
+	if shape != "cube" && shape != "sphere" {
+		return nil, fmt.Errorf("kernel must be cube or sphere, got %q", shape)
+	}
+
+	for dx := -radius; dx <= radius; dx++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dz := -radius; dz <= radius; dz++ {
+				if dx == 0 && dy == 0 && dz == 0 {
+					continue
+				}
+				if shape == "sphere" && dx*dx+dy*dy+dz*dz > radius*radius {
+					continue
+				}
+				offsets = append(offsets, [3]int{dx, dy, dz})
+			}
+		}
+	}
+
+	dist := func(o [3]int) int { return o[0]*o[0] + o[1]*o[1] + o[2]*o[2] }
+	sort.SliceStable(offsets, func(i, j int) bool {
+		return dist(offsets[i]) < dist(offsets[j])
+	})
+	return
+}
+
+// Component is a connected group of filled voxels.
+type Component struct {
+	Voxels []int
+}
+
+// Components labels the connected components of filled voxels, largest first.
+func (vol *Volume) Components(connectivity int) (components []Component, err error) {
+	offsets, err := neighborOffsets(connectivity)
+	if err != nil {
+		return
+	}
+
+	visited := make([]bool, vol.MaxX*vol.MaxY*vol.MaxZ)
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				k := vol.index(x, y, z)
+				if !vol.Filled[x][y][z] || visited[k] {
+					continue
+				}
+
+				visited[k] = true
+				comp := Component{Voxels: []int{k}}
+				for i := 0; i < len(comp.Voxels); i++ {
+					cx, cy, cz := vol.coords(comp.Voxels[i])
+					for _, d := range offsets {
+						nx, ny, nz := cx+d[0], cy+d[1], cz+d[2]
+						if !vol.IsFilled(nx, ny, nz) {
+							continue
+						}
+						n := vol.index(nx, ny, nz)
+						if !visited[n] {
+							visited[n] = true
+							comp.Voxels = append(comp.Voxels, n)
+						}
+					}
+				}
+
+				components = append(components, comp)
+			}
+		}
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i].Voxels) > len(components[j].Voxels)
+	})
+	return
+}
+
+// morph applies erosion (all kernel neighbors must be filled) or dilation
+// (any kernel neighbor filled) to the voxels in the box lo..hi.
+func (vol *Volume) morph(filled [][][]bool, offsets [][3]int, lo, hi [3]int, erode bool) [][][]bool {
+	out := newMask(vol.MaxX, vol.MaxY, vol.MaxZ)
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				if erode && !filled[x][y][z] {
+					continue
+				}
+				if !erode && filled[x][y][z] {
+					out[x][y][z] = true
+					continue
+				}
+
+				result := erode
+				for _, d := range offsets {
+					nx, ny, nz := x+d[0], y+d[1], z+d[2]
+					in := vol.Contains(nx, ny, nz) && filled[nx][ny][nz]
+					if erode && !in {
+						result = false
+						break
+					}
+					if !erode && in {
+						result = true
+						break
+					}
+				}
+				out[x][y][z] = result
+			}
+		}
+	}
+	return out
+}
+
+// grow expands a bounding box by r, clamped to the grid.
+func (vol *Volume) grow(lo, hi [3]int, r int) ([3]int, [3]int) {
+	last := [3]int{vol.MaxX - 1, vol.MaxY - 1, vol.MaxZ - 1}
+	for a := 0; a < 3; a++ {
+		lo[a] -= r
+		if lo[a] < 0 {
+			lo[a] = 0
+		}
+		hi[a] += r
+		if hi[a] > last[a] {
+			hi[a] = last[a]
+		}
+	}
+	return lo, hi
+}
+
+// Cleanup removes small floating components and applies morphological
+// opening and closing to the volume.
+func (vol *Volume) Cleanup(opts CleanupOptions) (report CleanupReport, err error) {
+	if opts.MinComponent > 0 {
+		var components []Component
+		components, err = vol.Components(opts.Connectivity)
+		if err != nil {
+			return
+		}
+
+		report.Components = len(components)
+		for _, comp := range components {
+			if len(comp.Voxels) >= opts.MinComponent {
+				continue
+			}
+			report.RemovedComponents++
+			report.RemovedComponentVoxels += len(comp.Voxels)
+			for _, k := range comp.Voxels {
+				x, y, z := vol.coords(k)
+				vol.Filled[x][y][z] = false
+			}
+		}
+	}
+
+	if opts.Open > 0 {
+		var offsets [][3]int
+		offsets, err = kernelOffsets(opts.Open, opts.Kernel)
+		if err != nil {
+			return
+		}
+
+		if lo, hi, ok := vol.Bounds(); ok {
+			lo, hi = vol.grow(lo, hi, opts.Open)
+			opened := vol.morph(vol.morph(vol.Filled, offsets, lo, hi, true), offsets, lo, hi, false)
+
+			// opening only ever removes voxels, so the colors stay put:
+			for x := lo[0]; x <= hi[0]; x++ {
+				for y := lo[1]; y <= hi[1]; y++ {
+					for z := lo[2]; z <= hi[2]; z++ {
+						if vol.Filled[x][y][z] && !opened[x][y][z] {
+							vol.Filled[x][y][z] = false
+							report.RemovedByOpen++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if opts.Close > 0 {
+		var offsets [][3]int
+		offsets, err = kernelOffsets(opts.Close, opts.Kernel)
+		if err != nil {
+			return
+		}
+
+		if lo, hi, ok := vol.Bounds(); ok {
+			lo, hi = vol.grow(lo, hi, opts.Close)
+			closed := vol.morph(vol.morph(vol.Filled, offsets, lo, hi, false), offsets, lo, hi, true)
+
+			// new voxels take the color of the nearest original voxel:
+			for x := lo[0]; x <= hi[0]; x++ {
+				for y := lo[1]; y <= hi[1]; y++ {
+					for z := lo[2]; z <= hi[2]; z++ {
+						if vol.Filled[x][y][z] || !closed[x][y][z] {
+							continue
+						}
+						for _, d := range offsets {
+							nx, ny, nz := x+d[0], y+d[1], z+d[2]
+							if vol.IsFilled(nx, ny, nz) {
+								vol.Voxels[x][y][z] = vol.Voxels[nx][ny][nz]
+								break
+							}
+						}
+					}
+				}
+			}
+			for x := lo[0]; x <= hi[0]; x++ {
+				for y := lo[1]; y <= hi[1]; y++ {
+					for z := lo[2]; z <= hi[2]; z++ {
+						if !vol.Filled[x][y][z] && closed[x][y][z] {
+							vol.Filled[x][y][z] = true
+							report.AddedByClose++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestCleanupComponents(t *testing.T) {
+	// a 3x3x3 body, a single voxel touching its corner diagonally and a
+	// two-voxel island well away from it:
+	newVol := func() *Volume {
+		vol := NewVolume(10, 10, 10)
+		for x := 1; x <= 3; x++ {
+			for y := 1; y <= 3; y++ {
+				for z := 1; z <= 3; z++ {
+					vol.Filled[x][y][z] = true
+				}
+			}
+		}
+		vol.Filled[4][4][4] = true
+		vol.Filled[8][8][8] = true
+		vol.Filled[8][8][7] = true
+		return vol
+	}
+
+	tests := []struct {
+		connectivity int
+		components   int
+		removed      int
+		count        int
+		corner       bool
+	}{
+		// the corner voxel only joins the body through a corner:
+		{26, 2, 1, 28, true},
+		{6, 3, 2, 27, false},
+	}
+	for _, tt := range tests {
+		vol := newVol()
+		report, err := vol.Cleanup(CleanupOptions{Connectivity: tt.connectivity, MinComponent: 5, Kernel: "cube"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Components != tt.components || report.RemovedComponents != tt.removed {
+			t.Errorf("connectivity %d: %d components, %d removed; want %d, %d", tt.connectivity, report.Components, report.RemovedComponents, tt.components, tt.removed)
+		}
+		if n := vol.Count(); n != tt.count {
+			t.Errorf("connectivity %d: %d voxels left, want %d", tt.connectivity, n, tt.count)
+		}
+		if vol.Filled[8][8][8] || vol.Filled[8][8][7] {
+			t.Errorf("connectivity %d: island kept", tt.connectivity)
+		}
+		if !vol.Filled[2][2][2] {
+			t.Errorf("connectivity %d: body removed", tt.connectivity)
+		}
+		if vol.Filled[4][4][4] != tt.corner {
+			t.Errorf("connectivity %d: corner voxel kept = %v, want %v", tt.connectivity, vol.Filled[4][4][4], tt.corner)
+		}
+	}
+
+	if _, err := newVol().Cleanup(CleanupOptions{Connectivity: 18, MinComponent: 5}); err == nil {
+		t.Error("connectivity 18 accepted")
+	}
+}
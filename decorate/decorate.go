@@ -0,0 +1,602 @@
+// Package decorate reads actor definitions from DECORATE and from the
+// States blocks of ZScript classes, as far as needed to find the sprite
+// frames each state of an actor shows. The actors of the Doom executable are
+// built in and can be patched with DEHACKED.
+package decorate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// State is one entry of an actor's state sequence: a sprite frame shown for
+// a number of tics.
+type State struct {
+	Sprite string
+	Frame  byte
+	// Tics is -1 for states that last forever and 0 where the duration is
+	// not a plain number, e.g. random(2, 4).
+	Tics   int
+	Bright bool
+	// End is set on the last state before a Stop, Loop, Wait, Fail or Goto.
+	End bool
+}
+
+// Actor is an actor class with its state labels.
+type Actor struct {
+	Name     string
+	Parent   string
+	Replaces string
+	// DoomEdNum is the editor number from a DECORATE header, -1 if none.
+	DoomEdNum int
+
+	States []State
+	// Labels maps each lower-cased state label, e.g. "spawn" or
+	// "death.fire", to the index of its first state in States; -1 for
+	// labels that stop right away.
+	Labels map[string]int
+	// Gotos maps labels that jump straight to another label to its name as
+	// written, e.g. "See", "See+2" or "Super::Pain".
+	Gotos map[string]string
+	// Order lists the state labels as written, in order of appearance.
+	Order []string
+}
+
+// maxSequence bounds the states followed for one label, so that malformed
+// definitions cannot run on.
+const maxSequence = 1024
+
+// Sequence returns the states shown from label on until the sequence stops,
+// loops or jumps elsewhere.
+func (a *Actor) Sequence(label string) []State {
+	start, ok := a.resolve(label)
+	if !ok {
+		return nil
+	}
+	var seq []State
+	for i := start; i < len(a.States) && len(seq) < maxSequence; i++ {
+		seq = append(seq, a.States[i])
+		if a.States[i].End {
+			break
+		}
+	}
+	return seq
+}
+
+// resolve returns the index of the first state of label, following Gotos
+// and their offsets. Labels qualified with an actor name that Find has not
+// resolved fall back to the plain label.
+func (a *Actor) resolve(label string) (int, bool) {
+	label = strings.ToLower(label)
+	offset := 0
+	for hops := 0; hops < 16; hops++ {
+		if target, ok := a.Gotos[label]; ok {
+			target = strings.ToLower(strings.ReplaceAll(target, " ", ""))
+			if i := strings.IndexByte(target, '+'); i >= 0 {
+				n, err := strconv.Atoi(target[i+1:])
+				if err != nil {
+					return 0, false
+				}
+				offset += n
+				target = target[:i]
+			}
+			label = target
+			continue
+		}
+		if _, ok := a.Labels[label]; !ok {
+			if i := strings.LastIndex(label, "::"); i >= 0 {
+				label = label[i+2:]
+				continue
+			}
+		}
+		break
+	}
+
+	start, ok := a.Labels[label]
+	if !ok || start < 0 || start+offset >= len(a.States) {
+		return 0, false
+	}
+	return start + offset, true
+}
+
+// SpriteFrames are the frame letters shown of one sprite, e.g. CYBR and
+// ABCD.
+type SpriteFrames struct {
+	Sprite string
+	Frames string
+}
+
+// Frames returns the sprite frames the states of the given labels show, or
+// of all labels if none are given, in order of first appearance. TNT1, the
+// invisible sprite, is left out.
+func (a *Actor) Frames(labels ...string) (frames []SpriteFrames) {
+	if len(labels) == 0 {
+		labels = a.Order
+	}
+
+	index := map[string]int{}
+	for _, label := range labels {
+		for _, s := range a.Sequence(label) {
+			if s.Sprite == "TNT1" {
+				continue
+			}
+			i, ok := index[s.Sprite]
+			if !ok {
+				i = len(frames)
+				index[s.Sprite] = i
+				frames = append(frames, SpriteFrames{Sprite: s.Sprite})
+			}
+			if !strings.ContainsRune(frames[i].Frames, rune(s.Frame)) {
+				frames[i].Frames += string(s.Frame)
+			}
+		}
+	}
+	return
+}
+
+// Find returns the actor of the given name, compared without regard to case,
+// with the states of its ancestors that it does not override. The labels of
+// every class in the chain are also kept qualified with its name, e.g.
+// "zombieman::see", and Super:: in Gotos is resolved to them.
+func Find(actors []*Actor, name string) (*Actor, error) {
+	byName := map[string]*Actor{}
+	for _, a := range actors {
+		byName[strings.ToLower(a.Name)] = a
+	}
+
+	a, ok := byName[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("actor %q is not defined", name)
+	}
+
+	merged := *a
+	merged.States = append([]State(nil), a.States...)
+	merged.Labels = map[string]int{}
+	merged.Gotos = map[string]string{}
+	merged.Order = append([]string(nil), a.Order...)
+	for k, v := range a.Labels {
+		merged.Labels[k] = v
+	}
+	for k, v := range a.Gotos {
+		merged.Gotos[k] = v
+	}
+
+	// the class each Goto was written in, to resolve Super:: against:
+	owner := map[string]*Actor{}
+	for k := range merged.Gotos {
+		owner[k] = a
+	}
+	qualify(&merged, owner, a, 0)
+
+	seen := map[string]bool{strings.ToLower(a.Name): true}
+	child := a
+	for p := byName[strings.ToLower(a.Parent)]; p != nil && !seen[strings.ToLower(p.Name)]; p = byName[strings.ToLower(p.Parent)] {
+		seen[strings.ToLower(p.Name)] = true
+		offset := len(merged.States)
+		merged.States = append(merged.States, p.States...)
+		qualify(&merged, owner, p, offset)
+
+		for k, target := range merged.Gotos {
+			if owner[k] == child && len(target) > 7 && strings.EqualFold(target[:7], "super::") {
+				merged.Gotos[k] = p.Name + "::" + target[7:]
+			}
+		}
+
+		for _, label := range p.Order {
+			key := strings.ToLower(label)
+			if _, ok := merged.Labels[key]; ok {
+				continue
+			}
+			if _, ok := merged.Gotos[key]; ok {
+				continue
+			}
+			merged.Order = append(merged.Order, label)
+			if target, ok := p.Gotos[key]; ok {
+				merged.Gotos[key] = target
+				owner[key] = p
+			} else if i := p.Labels[key]; i >= 0 {
+				merged.Labels[key] = i + offset
+			} else {
+				merged.Labels[key] = -1
+			}
+		}
+		child = p
+	}
+	return &merged, nil
+}
+
+// qualify adds the labels and Gotos of class c, whose states start at
+// offset in the merged actor, under names qualified with the class name.
+func qualify(merged *Actor, owner map[string]*Actor, c *Actor, offset int) {
+	prefix := strings.ToLower(c.Name) + "::"
+	for k, i := range c.Labels {
+		if i >= 0 {
+			i += offset
+		}
+		merged.Labels[prefix+k] = i
+	}
+	for k, target := range c.Gotos {
+		merged.Gotos[prefix+k] = target
+		owner[prefix+k] = c
+	}
+}
+
+// Parse reads the actors of DECORATE or ZScript source. Actor properties,
+// flags and functions are skipped; only headers and States blocks are read.
+// #include directives are not followed.
+func Parse(src string) (actors []*Actor, err error) {
+	s := stripComments(src)
+
+	for pos := 0; pos < len(s); {
+		word, next := readWord(s, pos)
+		if word == "" {
+			pos = next
+			if pos >= len(s) {
+				break
+			}
+			switch s[pos] {
+			case '{':
+				end, err := matchBrace(s, pos)
+				if err != nil {
+					return nil, err
+				}
+				pos = end + 1
+			case '"':
+				pos = skipString(s, pos)
+			default:
+				pos++
+			}
+			continue
+		}
+		pos = next
+
+		kind := strings.ToLower(word)
+		if kind != "actor" && kind != "class" {
+			continue
+		}
+
+		open := strings.IndexByte(s[pos:], '{')
+		if open < 0 {
+			return nil, fmt.Errorf("%s without a body", word)
+		}
+		open += pos
+		end, err := matchBrace(s, open)
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := parseHeader(s[pos:open])
+		if err != nil {
+			return nil, err
+		}
+		if err = a.parseBody(s[open+1 : end]); err != nil {
+			return nil, fmt.Errorf("actor %s: %w", a.Name, err)
+		}
+		actors = append(actors, a)
+		pos = end + 1
+	}
+	return
+}
+
+// parseHeader reads "Name [: Parent] [replaces Other] [doomednum] [native]".
+func parseHeader(h string) (*Actor, error) {
+	fields := strings.Fields(strings.ReplaceAll(h, ":", " : "))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("actor without a name")
+	}
+
+	a := &Actor{Name: fields[0], DoomEdNum: -1}
+	for i := 1; i < len(fields); i++ {
+		switch strings.ToLower(fields[i]) {
+		case ":":
+			if i+1 < len(fields) {
+				i++
+				a.Parent = fields[i]
+			}
+		case "replaces":
+			if i+1 < len(fields) {
+				i++
+				a.Replaces = fields[i]
+			}
+		default:
+			if n, err := strconv.Atoi(fields[i]); err == nil {
+				a.DoomEdNum = n
+			}
+		}
+	}
+	return a, nil
+}
+
+// parseBody finds the States block of an actor body.
+func (a *Actor) parseBody(body string) error {
+	a.Labels = map[string]int{}
+	a.Gotos = map[string]string{}
+
+	for pos := 0; pos < len(body); {
+		word, next := readWord(body, pos)
+		if word == "" {
+			pos = next
+			if pos >= len(body) {
+				break
+			}
+			switch body[pos] {
+			case '{':
+				end, err := matchBrace(body, pos)
+				if err != nil {
+					return err
+				}
+				pos = end + 1
+			case '"':
+				pos = skipString(body, pos)
+			default:
+				pos++
+			}
+			continue
+		}
+		pos = next
+		if !strings.EqualFold(word, "states") {
+			continue
+		}
+
+		// ZScript may qualify the block, as in States(Actor):
+		open := skipSpace(body, pos)
+		if open < len(body) && body[open] == '(' {
+			if close := strings.IndexByte(body[open:], ')'); close >= 0 {
+				open = skipSpace(body, open+close+1)
+			}
+		}
+		if open >= len(body) || body[open] != '{' {
+			continue
+		}
+		end, err := matchBrace(body, open)
+		if err != nil {
+			return err
+		}
+		if err = a.parseStates(body[open+1 : end]); err != nil {
+			return err
+		}
+		pos = end + 1
+	}
+	return nil
+}
+
+// parseStates reads the statements of a States block. DECORATE ends them at
+// line breaks and ZScript with semicolons; either works.
+func (a *Actor) parseStates(block string) error {
+	var pending []string
+	prevSprite, prevFrame := "", byte('A')
+
+	for _, stmt := range splitStatements(block) {
+		fields := splitFields(stmt)
+
+		// labels, possibly followed by a state on the same line:
+		for len(fields) > 0 {
+			label := ""
+			switch {
+			case strings.HasSuffix(fields[0], ":") && !strings.Contains(fields[0], "::"):
+				label = strings.TrimSuffix(fields[0], ":")
+				fields = fields[1:]
+			case len(fields) > 1 && fields[1] == ":":
+				label = fields[0]
+				fields = fields[2:]
+			}
+			if label == "" {
+				break
+			}
+			a.Order = append(a.Order, label)
+			pending = append(pending, strings.ToLower(label))
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "stop", "loop", "wait", "fail", "goto":
+			target := ""
+			if strings.EqualFold(fields[0], "goto") && len(fields) > 1 {
+				// the offset may be written apart, as in Goto See + 2:
+				target = strings.Join(fields[1:], "")
+			}
+			if len(pending) > 0 {
+				// labels without states of their own:
+				for _, label := range pending {
+					if target != "" {
+						a.Gotos[label] = target
+					} else {
+						a.Labels[label] = -1
+					}
+				}
+				pending = nil
+			} else if len(a.States) > 0 {
+				a.States[len(a.States)-1].End = true
+			}
+			continue
+		}
+
+		if len(fields) < 3 {
+			return fmt.Errorf("bad state %q", strings.TrimSpace(stmt))
+		}
+		sprite := strings.ToUpper(strings.Trim(fields[0], `"`))
+		if sprite == "####" || sprite == "----" {
+			sprite = prevSprite
+		}
+		if len(sprite) != 4 {
+			return fmt.Errorf("bad sprite name in state %q", strings.TrimSpace(stmt))
+		}
+		tics, err := strconv.Atoi(fields[2])
+		if err != nil {
+			tics = 0
+		}
+		bright := false
+		for _, f := range fields[3:] {
+			if strings.EqualFold(f, "bright") {
+				bright = true
+			}
+		}
+
+		for _, label := range pending {
+			a.Labels[label] = len(a.States)
+		}
+		pending = nil
+
+		for _, ch := range []byte(strings.ToUpper(strings.Trim(fields[1], `"`))) {
+			if ch == '#' {
+				ch = prevFrame
+			}
+			a.States = append(a.States, State{Sprite: sprite, Frame: ch, Tics: tics, Bright: bright})
+			prevFrame = ch
+		}
+		prevSprite = sprite
+	}
+
+	// labels at the very end have nothing to show:
+	for _, label := range pending {
+		a.Labels[label] = -1
+	}
+	return nil
+}
+
+// stripComments blanks out // and /* */ comments, keeping line breaks and
+// the contents of strings.
+func stripComments(src string) string {
+	b := []byte(src)
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '"':
+			i = skipString(src, i) - 1
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			for ; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				if b[i] != '\n' {
+					b[i] = ' '
+				}
+			}
+			if i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return string(b)
+}
+
+// skipString returns the position after the string starting at pos.
+func skipString(s string, pos int) int {
+	for i := pos + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// matchBrace returns the position of the brace closing the one at open.
+func matchBrace(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			i = skipString(s, i) - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed { at line %d", strings.Count(s[:open], "\n")+1)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// skipSpace returns the position of the first character at or after pos
+// that is not white space.
+func skipSpace(s string, pos int) int {
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t' || s[pos] == '\r' || s[pos] == '\n') {
+		pos++
+	}
+	return pos
+}
+
+// readWord returns the identifier at pos, skipping white space, and the
+// position after it; the word is empty if something else comes first.
+func readWord(s string, pos int) (string, int) {
+	start := skipSpace(s, pos)
+	pos = start
+	for pos < len(s) && isWordByte(s[pos]) {
+		pos++
+	}
+	return s[start:pos], pos
+}
+
+// splitStatements cuts a States block at line breaks and semicolons outside
+// parentheses, braces and strings.
+func splitStatements(block string) (stmts []string) {
+	depth := 0
+	start := 0
+	for i := 0; i < len(block); i++ {
+		switch block[i] {
+		case '"':
+			i = skipString(block, i) - 1
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case '\n', ';':
+			if depth <= 0 {
+				stmts = append(stmts, block[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(stmts, block[start:])
+}
+
+// splitFields splits a statement at white space outside parentheses, braces
+// and strings, and splits off a colon following a label.
+func splitFields(stmt string) (fields []string) {
+	depth := 0
+	start := -1
+	flush := func(i int) {
+		if start >= 0 {
+			fields = append(fields, stmt[start:i])
+			start = -1
+		}
+	}
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case c == '"' && depth == 0:
+			if start < 0 {
+				start = i
+			}
+			i = skipString(stmt, i) - 1
+		case c == '(' || c == '{':
+			depth++
+			if start < 0 {
+				start = i
+			}
+		case c == ')' || c == '}':
+			depth--
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\r'):
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(stmt))
+	return
+}
@@ -0,0 +1,248 @@
+package decorate
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testDecorate = `
+// a base class and an actor deriving from it
+ACTOR Base
+{
+	Health 100
+	+SOLID
+	States
+	{
+	Spawn:
+		BASE A 10
+		Loop
+	See:
+		BASE AB 4
+		BASE "#" 4 A_Chase
+		Loop
+	Pain:
+		BASE C 3 A_Pain
+		Goto See
+	Death:
+		BASE D 5 Bright
+		BASE E -1
+		Stop
+	}
+}
+
+actor Grunt : Base replaces Zombie 3004
+{
+	States
+	{
+	Spawn:
+		GRNT AB random(2, 4)
+		#### C 5
+		Loop
+	Missile:
+		GRNT E 10 A_FaceTarget
+		GRNT F 8 BRIGHT A_PosAttack
+		GRNT E 8
+		Goto See
+	Pain:
+		Goto Super::Pain
+	Pain.Fire:
+		Goto Missile + 1
+	XDeath:
+		Stop
+	}
+}
+`
+
+const testZScript = `
+class Grunt2 : Grunt
+{
+	Default
+	{
+		Health 30;
+	}
+	States
+	{
+	Spawn:
+		GRN2 A 10 { A_Look(); }
+		GRN2 B 10;
+		loop;
+	See:
+		GRN2 A 4 A_Chase();
+		"----" B 4;
+		loop;
+	Pain:
+		goto Super::Pain;
+	Melee: GRN2 G 6 bright; stop;
+	}
+
+	void A_Shout() { A_StartSound("grunt/shout"); }
+}
+`
+
+func TestParse(t *testing.T) {
+	actors, err := Parse(testDecorate + testZScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actors) != 3 {
+		t.Fatalf("got %d actors", len(actors))
+	}
+
+	base, grunt, grunt2 := actors[0], actors[1], actors[2]
+	if base.Name != "Base" || base.Parent != "" || base.DoomEdNum != -1 {
+		t.Errorf("Base = %+v", base)
+	}
+	if grunt.Name != "Grunt" || grunt.Parent != "Base" || grunt.Replaces != "Zombie" || grunt.DoomEdNum != 3004 {
+		t.Errorf("Grunt = %+v", grunt)
+	}
+	if grunt2.Name != "Grunt2" || grunt2.Parent != "Grunt" {
+		t.Errorf("Grunt2 = %+v", grunt2)
+	}
+
+	if want := []string{"Spawn", "See", "Pain", "Death"}; !reflect.DeepEqual(base.Order, want) {
+		t.Errorf("Base order %v, want %v", base.Order, want)
+	}
+	if want := map[string]string{"pain": "Super::Pain", "pain.fire": "Missile+1"}; !reflect.DeepEqual(grunt.Gotos, want) {
+		t.Errorf("Grunt gotos %v, want %v", grunt.Gotos, want)
+	}
+	if grunt.Labels["xdeath"] != -1 {
+		t.Errorf("Grunt XDeath at %d", grunt.Labels["xdeath"])
+	}
+
+	tests := []struct {
+		actor *Actor
+		label string
+		want  []State
+	}{
+		{base, "See", []State{
+			{Sprite: "BASE", Frame: 'A', Tics: 4},
+			{Sprite: "BASE", Frame: 'B', Tics: 4},
+			{Sprite: "BASE", Frame: 'B', Tics: 4, End: true},
+		}},
+		{base, "death", []State{
+			{Sprite: "BASE", Frame: 'D', Tics: 5, Bright: true},
+			{Sprite: "BASE", Frame: 'E', Tics: -1, End: true},
+		}},
+		// random tics are not plain numbers:
+		{grunt, "Spawn", []State{
+			{Sprite: "GRNT", Frame: 'A'},
+			{Sprite: "GRNT", Frame: 'B'},
+			{Sprite: "GRNT", Frame: 'C', Tics: 5, End: true},
+		}},
+		{grunt, "Pain.Fire", []State{
+			{Sprite: "GRNT", Frame: 'F', Tics: 8, Bright: true},
+			{Sprite: "GRNT", Frame: 'E', Tics: 8, End: true},
+		}},
+		{grunt2, "see", []State{
+			{Sprite: "GRN2", Frame: 'A', Tics: 4},
+			{Sprite: "GRN2", Frame: 'B', Tics: 4, End: true},
+		}},
+		{grunt2, "Melee", []State{
+			{Sprite: "GRN2", Frame: 'G', Tics: 6, Bright: true, End: true},
+		}},
+		{grunt, "XDeath", nil},
+		{grunt, "Raise", nil},
+	}
+	for _, tt := range tests {
+		if got := tt.actor.Sequence(tt.label); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s: got %+v, want %+v", tt.actor.Name, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"actor Broken { States { Spawn: BROK A } }",
+		"actor Broken { States { Spawn: TOOLONG A 4 } }",
+		"actor Broken { States { Spawn: BROK A 4 }",
+		"actor Broken",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("%q: no error", src)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	actors, err := Parse(testDecorate + testZScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(actors, "Nobody"); err == nil {
+		t.Error("found an undefined actor")
+	}
+
+	grunt2, err := Find(actors, "GRUNT2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Spawn", "See", "Pain", "Melee", "Missile", "Pain.Fire", "XDeath", "Death"}; !reflect.DeepEqual(grunt2.Order, want) {
+		t.Errorf("order %v, want %v", grunt2.Order, want)
+	}
+
+	tests := []struct {
+		label string
+		want  []State
+	}{
+		// Grunt2's own Spawn overrides its parents':
+		{"Spawn", []State{
+			{Sprite: "GRN2", Frame: 'A', Tics: 10},
+			{Sprite: "GRN2", Frame: 'B', Tics: 10, End: true},
+		}},
+		// inherited from Grunt:
+		{"Missile", []State{
+			{Sprite: "GRNT", Frame: 'E', Tics: 10},
+			{Sprite: "GRNT", Frame: 'F', Tics: 8, Bright: true},
+			{Sprite: "GRNT", Frame: 'E', Tics: 8, End: true},
+		}},
+		{"Pain.Fire", []State{
+			{Sprite: "GRNT", Frame: 'F', Tics: 8, Bright: true},
+			{Sprite: "GRNT", Frame: 'E', Tics: 8, End: true},
+		}},
+		// Super::Pain of Grunt2 is Grunt's, whose Super::Pain is Base's:
+		{"Pain", []State{
+			{Sprite: "BASE", Frame: 'C', Tics: 3, End: true},
+		}},
+		// inherited from Base:
+		{"Death", []State{
+			{Sprite: "BASE", Frame: 'D', Tics: 5, Bright: true},
+			{Sprite: "BASE", Frame: 'E', Tics: -1, End: true},
+		}},
+		// qualified with the class that defines them:
+		{"Grunt::Spawn", []State{
+			{Sprite: "GRNT", Frame: 'A'},
+			{Sprite: "GRNT", Frame: 'B'},
+			{Sprite: "GRNT", Frame: 'C', Tics: 5, End: true},
+		}},
+		{"Base::See", []State{
+			{Sprite: "BASE", Frame: 'A', Tics: 4},
+			{Sprite: "BASE", Frame: 'B', Tics: 4},
+			{Sprite: "BASE", Frame: 'B', Tics: 4, End: true},
+		}},
+		{"XDeath", nil},
+	}
+	for _, tt := range tests {
+		if got := grunt2.Sequence(tt.label); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.label, got, tt.want)
+		}
+	}
+
+	want := []SpriteFrames{{Sprite: "GRN2", Frames: "ABG"}, {Sprite: "BASE", Frames: "CDE"}, {Sprite: "GRNT", Frames: "EF"}}
+	if got := grunt2.Frames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("frames %+v, want %+v", got, want)
+	}
+}
+
+func TestFindSuperWithoutParent(t *testing.T) {
+	actors, err := Parse("actor Lone { States { Spawn: LONE A -1 Stop\nPain: Goto Super::Pain } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lone, err := Find(actors, "Lone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lone.Sequence("Pain"); got != nil {
+		t.Errorf("Pain = %+v", got)
+	}
+}
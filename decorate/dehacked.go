@@ -0,0 +1,193 @@
+package decorate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDehackedStates bounds the state numbers a patch may use; DEHEXTRA
+// patches go up to 3999.
+const maxDehackedStates = 4096
+
+// maxDehackedThings bounds the thing numbers a patch may use; DEHEXTRA adds
+// things 151-250.
+const maxDehackedThings = 250
+
+// thingFields maps the DEHACKED field names of a thing's states to labels.
+var thingFields = map[string]int{
+	"initial frame":      SpawnLabel,
+	"first moving frame": SeeLabel,
+	"injury frame":       PainLabel,
+	"close attack frame": MeleeLabel,
+	"far attack frame":   MissileLabel,
+	"death frame":        DeathLabel,
+	"exploding frame":    XDeathLabel,
+	"respawn frame":      RaiseLabel,
+}
+
+// ApplyDehacked patches the tables with the Thing and Frame blocks of a
+// DEHACKED patch, along with sprite renames from Text blocks and the BEX
+// [SPRITES] section. Everything else a patch changes is skipped.
+func (info *Info) ApplyDehacked(src string) error {
+	lines := strings.Split(strings.ReplaceAll(src, "\r", ""), "\n")
+
+	var block string
+	var num int
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		keyword := strings.ToLower(fields[0])
+
+		if strings.HasPrefix(line, "[") {
+			block, num = strings.ToLower(strings.Trim(line, "[] ")), 0
+			continue
+		}
+		if len(fields) >= 2 && !strings.Contains(line, "=") {
+			if v, err := strconv.Atoi(fields[1]); err == nil {
+				switch keyword {
+				case "thing", "frame":
+					block, num = keyword, v
+					continue
+				case "text":
+					if len(fields) < 3 {
+						return fmt.Errorf("line %d: text block without a new length", n+1)
+					}
+					to, err := strconv.Atoi(fields[2])
+					if err != nil {
+						return fmt.Errorf("line %d: %w", n+1, err)
+					}
+					next, err := info.text(lines, n+1, v, to)
+					if err != nil {
+						return fmt.Errorf("line %d: %w", n+1, err)
+					}
+					n = next - 1
+					block = ""
+					continue
+				default:
+					// Pointer, Sound, Ammo, Weapon, Sprite, Cheat and Misc
+					// blocks hold nothing about sprites:
+					block = ""
+					continue
+				}
+			}
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:eq]))
+		value := strings.TrimSpace(line[eq+1:])
+
+		var err error
+		switch block {
+		case "thing":
+			err = info.setThing(num, key, value)
+		case "frame":
+			err = info.setState(num, key, value)
+		case "sprites":
+			info.renameSprite(strings.ToUpper(key), strings.ToUpper(value))
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n+1, err)
+		}
+	}
+	return nil
+}
+
+// setThing sets a field of thing number num, counted from 1, growing the
+// table for extended patches.
+func (info *Info) setThing(num int, key, value string) error {
+	if num < 1 || num > maxDehackedThings {
+		return fmt.Errorf("thing %d is out of range", num)
+	}
+	// extended things are named as in GZDoom, after their mobjtype index:
+	for len(info.Things) < num {
+		info.Things = append(info.Things, InfoThing{Name: fmt.Sprintf("Deh_Actor_%d", len(info.Things)), DoomEdNum: -1})
+	}
+	t := &info.Things[num-1]
+	if key == "id #" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("thing %d: %w", num, err)
+		}
+		t.DoomEdNum = v
+		return nil
+	}
+	label, ok := thingFields[key]
+	if !ok {
+		return nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("thing %d: %w", num, err)
+	}
+	t.States[label] = v
+	return nil
+}
+
+// setState sets a field of state number num, growing the table for
+// extended patches.
+func (info *Info) setState(num int, key, value string) error {
+	if num < 0 || num >= maxDehackedStates {
+		return fmt.Errorf("frame %d is out of range", num)
+	}
+	for len(info.States) <= num {
+		info.States = append(info.States, InfoState{Tics: -1})
+	}
+	s := &info.States[num]
+	var field *int
+	switch key {
+	case "sprite number":
+		field = &s.Sprite
+	case "sprite subnumber":
+		field = &s.Frame
+	case "duration":
+		field = &s.Tics
+	case "next frame":
+		field = &s.Next
+	default:
+		return nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("frame %d: %w", num, err)
+	}
+	*field = v
+	return nil
+}
+
+// text reads the old and new text of a Text block starting at line n and
+// renames the sprite if the old text is a sprite name. Line breaks count as
+// one character each. It returns the line after the block.
+func (info *Info) text(lines []string, n, from, to int) (int, error) {
+	var sb strings.Builder
+	for ; n < len(lines) && sb.Len() < from+to; n++ {
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(lines[n])
+	}
+	s := sb.String()
+	if len(s) < from+to {
+		return n, fmt.Errorf("text block ends early")
+	}
+	if from == 4 && to == 4 {
+		info.renameSprite(s[:4], s[4:8])
+	}
+	return n, nil
+}
+
+// renameSprite replaces the sprite name old, if there is one.
+func (info *Info) renameSprite(old, name string) {
+	for i, s := range info.Sprites {
+		if s == old {
+			info.Sprites[i] = name
+			return
+		}
+	}
+}
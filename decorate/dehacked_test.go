@@ -0,0 +1,87 @@
+package decorate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyDehacked(t *testing.T) {
+	info := Vanilla()
+	err := info.ApplyDehacked(strings.Join([]string{
+		"Patch File for DeHackEd v3.0",
+		"# a comment",
+		"Doom version = 21",
+		"",
+		"Thing 2 (Zombieman)",
+		"ID # = 3100",
+		"Initial frame = 176",
+		"Hit points = 40",
+		"",
+		"Frame 174",
+		"Sprite number = 0",
+		"Sprite subnumber = 32769",
+		"Duration = 5",
+		"Next frame = 174",
+		"",
+		"Frame 1500",
+		"Sprite number = 29",
+		"Duration = 8",
+		"Next frame = 1500",
+		"",
+		"Thing 200",
+		"Initial frame = 1500",
+		"",
+		"Pointer 12 (Frame 174)",
+		"Codep Frame = 1",
+		"",
+		"Text 4 4",
+		"POSSZOMB",
+		"",
+		"[SPRITES]",
+		"TROO = IMPS",
+		"",
+	}, "\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zombie := info.Things[1]
+	if zombie.DoomEdNum != 3100 || zombie.States[SpawnLabel] != 176 || zombie.States[SeeLabel] != 176 {
+		t.Errorf("thing 2 = %+v", zombie)
+	}
+	if got, want := info.States[174], (InfoState{0, 0x8001, 5, 174}); got != want {
+		t.Errorf("frame 174 = %+v, want %+v", got, want)
+	}
+	if got, want := info.States[1500], (InfoState{29, 0, 8, 1500}); got != want {
+		t.Errorf("frame 1500 = %+v, want %+v", got, want)
+	}
+	if len(info.States) != 1501 || info.States[1000].Tics != -1 {
+		t.Errorf("states grew to %d, state 1000 = %+v", len(info.States), info.States[1000])
+	}
+	if len(info.Things) != 200 || info.Things[199].Name != "Deh_Actor_199" || info.Things[199].States[SpawnLabel] != 1500 {
+		t.Errorf("things grew to %d, thing 200 = %+v", len(info.Things), info.Things[len(info.Things)-1])
+	}
+	if info.Sprites[29] != "ZOMB" || info.Sprites[0] != "IMPS" {
+		t.Errorf("sprites 0 and 29 = %s and %s", info.Sprites[0], info.Sprites[29])
+	}
+
+	// the patch works on a copy:
+	if v := Vanilla(); v.Sprites[29] != "POSS" || v.States[174].Sprite != 29 || len(v.Things) == 200 {
+		t.Errorf("patch changed the vanilla tables")
+	}
+}
+
+func TestApplyDehackedErrors(t *testing.T) {
+	for _, src := range []string{
+		"Thing 251\nInitial frame = 1\n",
+		"Thing 0\nInitial frame = 1\n",
+		"Frame 4096\nDuration = 1\n",
+		"Frame 10\nDuration = long\n",
+		"Text 4 4\nPOSS\n",
+		"Text 4\nPOSSZOMB\n",
+	} {
+		if err := Vanilla().ApplyDehacked(src); err == nil {
+			t.Errorf("%q: no error", src)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package decorate
+
+import "strings"
+
+// InfoState is an entry of the executable's state table. Frame holds the
+// frame number, with the bright bit (0x8000) set on fullbright states.
+type InfoState struct {
+	Sprite int
+	Frame  int
+	Tics   int
+	Next   int
+}
+
+// The state fields of a thing, in the order of InfoThing.States.
+const (
+	SpawnLabel = iota
+	SeeLabel
+	PainLabel
+	MeleeLabel
+	MissileLabel
+	DeathLabel
+	XDeathLabel
+	RaiseLabel
+	NumLabels
+)
+
+// infoLabels names the state fields of a thing as DECORATE labels.
+var infoLabels = [NumLabels]string{"Spawn", "See", "Pain", "Melee", "Missile", "Death", "XDeath", "Raise"}
+
+// InfoThing is an entry of the executable's thing table: the first state of
+// each of its labels, 0 where it has none.
+type InfoThing struct {
+	Name      string
+	DoomEdNum int
+	States    [NumLabels]int
+}
+
+// Info holds the sprite, state and thing tables of the Doom executable, which
+// DEHACKED patches modify.
+type Info struct {
+	Sprites []string
+	States  []InfoState
+	Things  []InfoThing
+}
+
+// Vanilla returns a copy of the tables of the Doom II v1.9 executable, which
+// Doom and Ultimate Doom share.
+func Vanilla() *Info {
+	return &Info{
+		Sprites: append([]string(nil), vanillaSprites...),
+		States:  append([]InfoState(nil), vanillaStates...),
+		Things:  append([]InfoThing(nil), vanillaThings...),
+	}
+}
+
+// Actors returns the things as actors whose labels hold the states each one
+// runs through until it stops, loops, stays or reaches another label, as a
+// Goto would in DECORATE.
+func (info *Info) Actors() []*Actor {
+	actors := make([]*Actor, 0, len(info.Things))
+	for _, t := range info.Things {
+		starts := map[int]bool{}
+		for _, start := range t.States {
+			starts[start] = true
+		}
+		a := &Actor{
+			Name:      t.Name,
+			DoomEdNum: t.DoomEdNum,
+			Labels:    map[string]int{},
+			Gotos:     map[string]string{},
+		}
+		for label, start := range t.States {
+			if start <= 0 || start >= len(info.States) {
+				continue
+			}
+			first := len(a.States)
+			seen := map[int]bool{}
+			for i := start; i > 0 && i < len(info.States) && !seen[i] && (i == start || !starts[i]) && len(a.States)-first < maxSequence; i = info.States[i].Next {
+				seen[i] = true
+				s := info.States[i]
+				a.States = append(a.States, State{
+					Sprite: info.sprite(s.Sprite),
+					Frame:  byte('A' + s.Frame&^bright),
+					Tics:   s.Tics,
+					Bright: s.Frame&bright != 0,
+				})
+				if s.Tics < 0 {
+					break
+				}
+			}
+			a.States[len(a.States)-1].End = true
+			a.Labels[strings.ToLower(infoLabels[label])] = first
+			a.Order = append(a.Order, infoLabels[label])
+		}
+		actors = append(actors, a)
+	}
+	return actors
+}
+
+// sprite returns the name of sprite number i, TNT1 for numbers out of range.
+func (info *Info) sprite(i int) string {
+	if i < 0 || i >= len(info.Sprites) {
+		return "TNT1"
+	}
+	return info.Sprites[i]
+}
@@ -0,0 +1,107 @@
+package decorate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInfoActors(t *testing.T) {
+	info := &Info{
+		Sprites: []string{"AAAA", "BBBB"},
+		States: []InfoState{
+			{},
+			// Spawn loops:
+			{0, 0, 5, 2},
+			{0, bright | 1, 5, 1},
+			// See runs into Spawn:
+			{1, 0, 4, 4},
+			{1, 1, 4, 5},
+			{1, 2, 4, 1},
+			// Death stays:
+			{1, bright | 3, 6, 7},
+			{1, 4, -1, 8},
+			{1, 5, 1, 0},
+			// a sprite number out of range:
+			{7, 0, 2, 0},
+		},
+		Things: []InfoThing{
+			{"Thing", 100, [NumLabels]int{SpawnLabel: 1, SeeLabel: 3, DeathLabel: 6, RaiseLabel: 9}},
+		},
+	}
+	actors := info.Actors()
+	if len(actors) != 1 {
+		t.Fatalf("got %d actors", len(actors))
+	}
+	a := actors[0]
+	if a.Name != "Thing" || a.DoomEdNum != 100 {
+		t.Errorf("got %s, %d", a.Name, a.DoomEdNum)
+	}
+	if want := []string{"Spawn", "See", "Death", "Raise"}; !reflect.DeepEqual(a.Order, want) {
+		t.Errorf("order %v, want %v", a.Order, want)
+	}
+
+	tests := []struct {
+		label string
+		want  []State
+	}{
+		{"Spawn", []State{
+			{Sprite: "AAAA", Frame: 'A', Tics: 5},
+			{Sprite: "AAAA", Frame: 'B', Tics: 5, Bright: true, End: true},
+		}},
+		{"see", []State{
+			{Sprite: "BBBB", Frame: 'A', Tics: 4},
+			{Sprite: "BBBB", Frame: 'B', Tics: 4},
+			{Sprite: "BBBB", Frame: 'C', Tics: 4, End: true},
+		}},
+		{"Death", []State{
+			{Sprite: "BBBB", Frame: 'D', Tics: 6, Bright: true},
+			{Sprite: "BBBB", Frame: 'E', Tics: -1, End: true},
+		}},
+		{"Raise", []State{
+			{Sprite: "TNT1", Frame: 'A', Tics: 2, End: true},
+		}},
+		{"Pain", nil},
+	}
+	for _, tt := range tests {
+		if got := a.Sequence(tt.label); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.label, got, tt.want)
+		}
+	}
+
+	want := []SpriteFrames{{Sprite: "AAAA", Frames: "AB"}, {Sprite: "BBBB", Frames: "ABCDE"}}
+	if got := a.Frames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("frames %+v, want %+v", got, want)
+	}
+}
+
+func TestVanillaActors(t *testing.T) {
+	actors := map[string]*Actor{}
+	for _, a := range Vanilla().Actors() {
+		actors[a.Name] = a
+	}
+
+	zombie := actors["ZombieMan"]
+	if zombie == nil || zombie.DoomEdNum != 3004 {
+		t.Fatalf("ZombieMan = %+v", zombie)
+	}
+	// in label order: Spawn, See, Pain, Missile, Death, XDeath, Raise:
+	want := []SpriteFrames{{Sprite: "POSS", Frames: "ABCDGEFHIJKLMNOPQRSTU"}}
+	if got := zombie.Frames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZombieMan frames %+v, want %+v", got, want)
+	}
+	for _, s := range zombie.States {
+		if s.Bright {
+			t.Errorf("ZombieMan state %s%c is bright", s.Sprite, s.Frame)
+		}
+	}
+
+	soul := actors["LostSoul"]
+	if soul == nil {
+		t.Fatal("no LostSoul")
+	}
+	for _, s := range soul.Sequence("Spawn") {
+		if !s.Bright {
+			t.Errorf("LostSoul spawn state %s%c is not bright", s.Sprite, s.Frame)
+		}
+	}
+}
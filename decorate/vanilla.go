@@ -0,0 +1,1140 @@
+package decorate
+
+// bright is the frame bit of fullbright states.
+const bright = 0x8000
+
+// vanillaSprites are the sprite names of the Doom II v1.9 executable, in
+// the order DEHACKED sprite numbers refer to.
+var vanillaSprites = []string{
+	"TROO", "SHTG", "PUNG", "PISG", "PISF", "SHTF", "SHT2", "CHGG", "CHGF",
+	"MISG", "MISF", "SAWG", "PLSG", "PLSF", "BFGG", "BFGF", "BLUD", "PUFF",
+	"BAL1", "BAL2", "PLSS", "PLSE", "MISL", "BFS1", "BFE1", "BFE2", "TFOG",
+	"IFOG", "PLAY", "POSS", "SPOS", "VILE", "FIRE", "FATB", "FBXP", "SKEL",
+	"MANF", "FATT", "CPOS", "SARG", "HEAD", "BAL7", "BOSS", "BOS2", "SKUL",
+	"SPID", "BSPI", "APLS", "APBX", "CYBR", "PAIN", "SSWV", "KEEN", "BBRN",
+	"BOSF", "ARM1", "ARM2", "BAR1", "BEXP", "FCAN", "BON1", "BON2", "BKEY",
+	"RKEY", "YKEY", "BSKU", "RSKU", "YSKU", "STIM", "MEDI", "SOUL", "PINV",
+	"PSTR", "PINS", "MEGA", "SUIT", "PMAP", "PVIS", "CLIP", "AMMO", "ROCK",
+	"BROK", "CELL", "CELP", "SHEL", "SBOX", "BPAK", "BFUG", "MGUN", "CSAW",
+	"LAUN", "PLAS", "SHOT", "SGN2", "COLU", "SMT2", "GOR1", "POL2", "POL5",
+	"POL4", "POL3", "POL1", "POL6", "GOR2", "GOR3", "GOR4", "GOR5", "SMIT",
+	"COL1", "COL2", "COL3", "COL4", "CAND", "CBRA", "COL6", "TRE1", "TRE2",
+	"ELEC", "CEYE", "FSKU", "COL5", "TBLU", "TGRN", "TRED", "SMBT", "SMGT",
+	"SMRT", "HDB1", "HDB2", "HDB3", "HDB4", "HDB5", "HDB6", "POB1", "POB2",
+	"BRS1", "TLMP", "TLP2",
+}
+
+// vanillaStates is the states[] table of the Doom II v1.9 executable,
+// without code pointers; DEHACKED frame numbers index it.
+var vanillaStates = []InfoState{
+	{0, 0, -1, 0},              // 0 S_NULL
+	{1, 4, 0, 0},               // 1 S_LIGHTDONE
+	{2, 0, 1, 2},               // 2 S_PUNCH
+	{2, 0, 1, 3},               // 3
+	{2, 0, 1, 4},               // 4
+	{2, 1, 4, 6},               // 5 S_PUNCH1
+	{2, 2, 4, 7},               // 6
+	{2, 3, 5, 8},               // 7
+	{2, 2, 4, 9},               // 8
+	{2, 1, 5, 2},               // 9
+	{3, 0, 1, 10},              // 10 S_PISTOL
+	{3, 0, 1, 11},              // 11
+	{3, 0, 1, 12},              // 12
+	{3, 0, 4, 14},              // 13 S_PISTOL1
+	{3, 1, 6, 15},              // 14
+	{3, 2, 4, 16},              // 15
+	{3, 1, 5, 10},              // 16
+	{4, bright | 0, 7, 1},      // 17 S_PISTOLFLASH
+	{1, 0, 1, 18},              // 18 S_SGUN
+	{1, 0, 1, 19},              // 19
+	{1, 0, 1, 20},              // 20
+	{1, 0, 3, 22},              // 21 S_SGUN1
+	{1, 0, 7, 23},              // 22
+	{1, 1, 5, 24},              // 23
+	{1, 2, 5, 25},              // 24
+	{1, 3, 4, 26},              // 25
+	{1, 2, 5, 27},              // 26
+	{1, 1, 5, 28},              // 27
+	{1, 0, 3, 29},              // 28
+	{1, 0, 7, 18},              // 29
+	{5, bright | 0, 4, 31},     // 30 S_SGUNFLASH1
+	{5, bright | 1, 3, 1},      // 31
+	{6, 0, 1, 32},              // 32 S_DSGUN
+	{6, 0, 1, 33},              // 33
+	{6, 0, 1, 34},              // 34
+	{6, 0, 3, 36},              // 35 S_DSGUN1
+	{6, 0, 7, 37},              // 36
+	{6, 1, 7, 38},              // 37
+	{6, 2, 7, 39},              // 38
+	{6, 3, 7, 40},              // 39
+	{6, 4, 7, 41},              // 40
+	{6, 5, 6, 42},              // 41
+	{6, 6, 6, 43},              // 42
+	{6, 0, 5, 32},              // 43
+	{6, 1, 7, 45},              // 44 S_DSGUN10
+	{6, 0, 3, 46},              // 45
+	{6, 0, 3, 32},              // 46
+	{6, bright | 8, 5, 48},     // 47 S_DSGUNFLASH1
+	{6, bright | 9, 4, 1},      // 48
+	{7, 0, 1, 49},              // 49 S_CHAIN
+	{7, 0, 1, 50},              // 50
+	{7, 0, 1, 51},              // 51
+	{7, 0, 4, 53},              // 52 S_CHAIN1
+	{7, 1, 4, 54},              // 53
+	{7, 1, 0, 49},              // 54
+	{8, bright | 0, 5, 1},      // 55 S_CHAINFLASH1
+	{8, bright | 1, 5, 1},      // 56
+	{9, 0, 1, 57},              // 57 S_MISSILE
+	{9, 0, 1, 58},              // 58
+	{9, 0, 1, 59},              // 59
+	{9, 1, 8, 61},              // 60 S_MISSILE1
+	{9, 1, 12, 62},             // 61
+	{9, 1, 0, 57},              // 62
+	{10, bright | 0, 3, 64},    // 63 S_MISSILEFLASH1
+	{10, bright | 1, 4, 65},    // 64
+	{10, bright | 2, 4, 66},    // 65
+	{10, bright | 3, 4, 1},     // 66
+	{11, 2, 4, 68},             // 67 S_SAW
+	{11, 3, 4, 67},             // 68
+	{11, 2, 1, 69},             // 69
+	{11, 2, 1, 70},             // 70
+	{11, 0, 4, 72},             // 71 S_SAW1
+	{11, 1, 4, 73},             // 72
+	{11, 1, 0, 67},             // 73
+	{12, 0, 1, 74},             // 74 S_PLASMA
+	{12, 0, 1, 75},             // 75
+	{12, 0, 1, 76},             // 76
+	{12, 0, 3, 78},             // 77 S_PLASMA1
+	{12, 1, 20, 74},            // 78
+	{13, bright | 0, 4, 1},     // 79 S_PLASMAFLASH1
+	{13, bright | 1, 4, 1},     // 80
+	{14, 0, 1, 81},             // 81 S_BFG
+	{14, 0, 1, 82},             // 82
+	{14, 0, 1, 83},             // 83
+	{14, 0, 20, 85},            // 84 S_BFG1
+	{14, 1, 10, 86},            // 85
+	{14, 1, 10, 87},            // 86
+	{14, 1, 20, 81},            // 87
+	{15, bright | 0, 11, 89},   // 88 S_BFGFLASH1
+	{15, bright | 1, 6, 1},     // 89
+	{16, 2, 8, 91},             // 90 S_BLOOD1
+	{16, 1, 8, 92},             // 91
+	{16, 0, 8, 0},              // 92
+	{17, bright | 0, 4, 94},    // 93 S_PUFF1
+	{17, 1, 4, 95},             // 94
+	{17, 2, 4, 96},             // 95
+	{17, 3, 4, 0},              // 96
+	{18, bright | 0, 4, 98},    // 97 S_TBALL1
+	{18, bright | 1, 4, 97},    // 98
+	{18, bright | 2, 6, 100},   // 99 S_TBALLX1
+	{18, bright | 3, 6, 101},   // 100
+	{18, bright | 4, 6, 0},     // 101
+	{19, bright | 0, 4, 103},   // 102 S_RBALL1
+	{19, bright | 1, 4, 102},   // 103
+	{19, bright | 2, 6, 105},   // 104 S_RBALLX1
+	{19, bright | 3, 6, 106},   // 105
+	{19, bright | 4, 6, 0},     // 106
+	{20, bright | 0, 6, 108},   // 107 S_PLASBALL
+	{20, bright | 1, 6, 107},   // 108
+	{21, bright | 0, 4, 110},   // 109 S_PLASEXP
+	{21, bright | 1, 4, 111},   // 110
+	{21, bright | 2, 4, 112},   // 111
+	{21, bright | 3, 4, 113},   // 112
+	{21, bright | 4, 4, 0},     // 113
+	{22, bright | 0, 1, 114},   // 114 S_ROCKET
+	{23, bright | 0, 4, 116},   // 115 S_BFGSHOT
+	{23, bright | 1, 4, 115},   // 116
+	{24, bright | 0, 8, 118},   // 117 S_BFGLAND
+	{24, bright | 1, 8, 119},   // 118
+	{24, bright | 2, 8, 120},   // 119
+	{24, bright | 3, 8, 121},   // 120
+	{24, bright | 4, 8, 122},   // 121
+	{24, bright | 5, 8, 0},     // 122
+	{25, bright | 0, 8, 124},   // 123 S_BFGEXP
+	{25, bright | 1, 8, 125},   // 124
+	{25, bright | 2, 8, 126},   // 125
+	{25, bright | 3, 8, 0},     // 126
+	{22, bright | 1, 8, 128},   // 127 S_EXPLODE1
+	{22, bright | 2, 6, 129},   // 128
+	{22, bright | 3, 4, 0},     // 129
+	{26, bright | 0, 6, 131},   // 130 S_TFOG
+	{26, bright | 1, 6, 132},   // 131
+	{26, bright | 0, 6, 133},   // 132
+	{26, bright | 1, 6, 134},   // 133
+	{26, bright | 2, 6, 135},   // 134
+	{26, bright | 3, 6, 136},   // 135
+	{26, bright | 4, 6, 137},   // 136
+	{26, bright | 5, 6, 138},   // 137
+	{26, bright | 6, 6, 139},   // 138
+	{26, bright | 7, 6, 140},   // 139
+	{26, bright | 8, 6, 141},   // 140
+	{26, bright | 9, 6, 0},     // 141
+	{27, bright | 0, 6, 143},   // 142 S_IFOG
+	{27, bright | 1, 6, 144},   // 143
+	{27, bright | 0, 6, 145},   // 144
+	{27, bright | 1, 6, 146},   // 145
+	{27, bright | 2, 6, 147},   // 146
+	{27, bright | 3, 6, 148},   // 147
+	{27, bright | 4, 6, 0},     // 148
+	{28, 0, -1, 0},             // 149 S_PLAY
+	{28, 0, 4, 151},            // 150 S_PLAY_RUN1
+	{28, 1, 4, 152},            // 151
+	{28, 2, 4, 153},            // 152
+	{28, 3, 4, 150},            // 153
+	{28, 4, 12, 149},           // 154 S_PLAY_ATK1
+	{28, bright | 5, 6, 154},   // 155
+	{28, 6, 4, 157},            // 156 S_PLAY_PAIN
+	{28, 6, 4, 149},            // 157
+	{28, 7, 10, 159},           // 158 S_PLAY_DIE1
+	{28, 8, 10, 160},           // 159
+	{28, 9, 10, 161},           // 160
+	{28, 10, 10, 162},          // 161
+	{28, 11, 10, 163},          // 162
+	{28, 12, 10, 164},          // 163
+	{28, 13, -1, 0},            // 164
+	{28, 14, 5, 166},           // 165 S_PLAY_XDIE1
+	{28, 15, 5, 167},           // 166
+	{28, 16, 5, 168},           // 167
+	{28, 17, 5, 169},           // 168
+	{28, 18, 5, 170},           // 169
+	{28, 19, 5, 171},           // 170
+	{28, 20, 5, 172},           // 171
+	{28, 21, 5, 173},           // 172
+	{28, 22, -1, 0},            // 173
+	{29, 0, 10, 175},           // 174 S_POSS_STND
+	{29, 1, 10, 174},           // 175
+	{29, 0, 4, 177},            // 176 S_POSS_RUN1
+	{29, 0, 4, 178},            // 177
+	{29, 1, 4, 179},            // 178
+	{29, 1, 4, 180},            // 179
+	{29, 2, 4, 181},            // 180
+	{29, 2, 4, 182},            // 181
+	{29, 3, 4, 183},            // 182
+	{29, 3, 4, 176},            // 183
+	{29, 4, 10, 185},           // 184 S_POSS_ATK1
+	{29, 5, 8, 186},            // 185
+	{29, 4, 8, 176},            // 186
+	{29, 6, 3, 188},            // 187 S_POSS_PAIN
+	{29, 6, 3, 176},            // 188
+	{29, 7, 5, 190},            // 189 S_POSS_DIE1
+	{29, 8, 5, 191},            // 190
+	{29, 9, 5, 192},            // 191
+	{29, 10, 5, 193},           // 192
+	{29, 11, -1, 0},            // 193
+	{29, 12, 5, 195},           // 194 S_POSS_XDIE1
+	{29, 13, 5, 196},           // 195
+	{29, 14, 5, 197},           // 196
+	{29, 15, 5, 198},           // 197
+	{29, 16, 5, 199},           // 198
+	{29, 17, 5, 200},           // 199
+	{29, 18, 5, 201},           // 200
+	{29, 19, 5, 202},           // 201
+	{29, 20, -1, 0},            // 202
+	{29, 10, 5, 204},           // 203 S_POSS_RAISE1
+	{29, 9, 5, 205},            // 204
+	{29, 8, 5, 206},            // 205
+	{29, 7, 5, 176},            // 206
+	{30, 0, 10, 208},           // 207 S_SPOS_STND
+	{30, 1, 10, 207},           // 208
+	{30, 0, 3, 210},            // 209 S_SPOS_RUN1
+	{30, 0, 3, 211},            // 210
+	{30, 1, 3, 212},            // 211
+	{30, 1, 3, 213},            // 212
+	{30, 2, 3, 214},            // 213
+	{30, 2, 3, 215},            // 214
+	{30, 3, 3, 216},            // 215
+	{30, 3, 3, 209},            // 216
+	{30, 4, 10, 218},           // 217 S_SPOS_ATK1
+	{30, bright | 5, 10, 219},  // 218
+	{30, 4, 10, 209},           // 219
+	{30, 6, 3, 221},            // 220 S_SPOS_PAIN
+	{30, 6, 3, 209},            // 221
+	{30, 7, 5, 223},            // 222 S_SPOS_DIE1
+	{30, 8, 5, 224},            // 223
+	{30, 9, 5, 225},            // 224
+	{30, 10, 5, 226},           // 225
+	{30, 11, -1, 0},            // 226
+	{30, 12, 5, 228},           // 227 S_SPOS_XDIE1
+	{30, 13, 5, 229},           // 228
+	{30, 14, 5, 230},           // 229
+	{30, 15, 5, 231},           // 230
+	{30, 16, 5, 232},           // 231
+	{30, 17, 5, 233},           // 232
+	{30, 18, 5, 234},           // 233
+	{30, 19, 5, 235},           // 234
+	{30, 20, -1, 0},            // 235
+	{30, 11, 5, 237},           // 236 S_SPOS_RAISE1
+	{30, 10, 5, 238},           // 237
+	{30, 9, 5, 239},            // 238
+	{30, 8, 5, 240},            // 239
+	{30, 7, 5, 209},            // 240
+	{31, 0, 10, 242},           // 241 S_VILE_STND
+	{31, 1, 10, 241},           // 242
+	{31, 0, 2, 244},            // 243 S_VILE_RUN1
+	{31, 0, 2, 245},            // 244
+	{31, 1, 2, 246},            // 245
+	{31, 1, 2, 247},            // 246
+	{31, 2, 2, 248},            // 247
+	{31, 2, 2, 249},            // 248
+	{31, 3, 2, 250},            // 249
+	{31, 3, 2, 251},            // 250
+	{31, 4, 2, 252},            // 251
+	{31, 4, 2, 253},            // 252
+	{31, 5, 2, 254},            // 253
+	{31, 5, 2, 243},            // 254
+	{31, bright | 6, 0, 256},   // 255 S_VILE_ATK1
+	{31, bright | 6, 10, 257},  // 256
+	{31, bright | 7, 8, 258},   // 257
+	{31, bright | 8, 8, 259},   // 258
+	{31, bright | 9, 8, 260},   // 259
+	{31, bright | 10, 8, 261},  // 260
+	{31, bright | 11, 8, 262},  // 261
+	{31, bright | 12, 8, 263},  // 262
+	{31, bright | 13, 8, 264},  // 263
+	{31, bright | 14, 8, 265},  // 264
+	{31, bright | 15, 20, 243}, // 265
+	{31, bright | 26, 10, 267}, // 266 S_VILE_HEAL1
+	{31, bright | 27, 10, 268}, // 267
+	{31, bright | 28, 10, 243}, // 268
+	{31, 16, 5, 270},           // 269 S_VILE_PAIN
+	{31, 16, 5, 243},           // 270
+	{31, 16, 7, 272},           // 271 S_VILE_DIE1
+	{31, 17, 7, 273},           // 272
+	{31, 18, 7, 274},           // 273
+	{31, 19, 7, 275},           // 274
+	{31, 20, 7, 276},           // 275
+	{31, 21, 7, 277},           // 276
+	{31, 22, 7, 278},           // 277
+	{31, 23, 5, 279},           // 278
+	{31, 24, 5, 280},           // 279
+	{31, 25, -1, 0},            // 280
+	{32, bright | 0, 2, 282},   // 281 S_FIRE1
+	{32, bright | 1, 2, 283},   // 282
+	{32, bright | 0, 2, 284},   // 283
+	{32, bright | 1, 2, 285},   // 284
+	{32, bright | 2, 2, 286},   // 285
+	{32, bright | 1, 2, 287},   // 286
+	{32, bright | 2, 2, 288},   // 287
+	{32, bright | 1, 2, 289},   // 288
+	{32, bright | 2, 2, 290},   // 289
+	{32, bright | 3, 2, 291},   // 290
+	{32, bright | 2, 2, 292},   // 291
+	{32, bright | 3, 2, 293},   // 292
+	{32, bright | 2, 2, 294},   // 293
+	{32, bright | 3, 2, 295},   // 294
+	{32, bright | 4, 2, 296},   // 295
+	{32, bright | 3, 2, 297},   // 296
+	{32, bright | 4, 2, 298},   // 297
+	{32, bright | 3, 2, 299},   // 298
+	{32, bright | 4, 2, 300},   // 299
+	{32, bright | 5, 2, 301},   // 300
+	{32, bright | 4, 2, 302},   // 301
+	{32, bright | 5, 2, 303},   // 302
+	{32, bright | 4, 2, 304},   // 303
+	{32, bright | 5, 2, 305},   // 304
+	{32, bright | 6, 2, 306},   // 305
+	{32, bright | 7, 2, 307},   // 306
+	{32, bright | 6, 2, 308},   // 307
+	{32, bright | 7, 2, 309},   // 308
+	{32, bright | 6, 2, 310},   // 309
+	{32, bright | 7, 2, 0},     // 310
+	{17, 1, 4, 312},            // 311 S_SMOKE1
+	{17, 2, 4, 313},            // 312
+	{17, 1, 4, 314},            // 313
+	{17, 2, 4, 315},            // 314
+	{17, 3, 4, 0},              // 315
+	{33, bright | 0, 2, 317},   // 316 S_TRACER
+	{33, bright | 1, 2, 316},   // 317
+	{34, bright | 0, 8, 319},   // 318 S_TRACEEXP1
+	{34, bright | 1, 6, 320},   // 319
+	{34, bright | 2, 4, 0},     // 320
+	{35, 0, 10, 322},           // 321 S_SKEL_STND
+	{35, 1, 10, 321},           // 322
+	{35, 0, 2, 324},            // 323 S_SKEL_RUN1
+	{35, 0, 2, 325},            // 324
+	{35, 1, 2, 326},            // 325
+	{35, 1, 2, 327},            // 326
+	{35, 2, 2, 328},            // 327
+	{35, 2, 2, 329},            // 328
+	{35, 3, 2, 330},            // 329
+	{35, 3, 2, 331},            // 330
+	{35, 4, 2, 332},            // 331
+	{35, 4, 2, 333},            // 332
+	{35, 5, 2, 334},            // 333
+	{35, 5, 2, 323},            // 334
+	{35, 6, 0, 336},            // 335 S_SKEL_FIST1
+	{35, 6, 6, 337},            // 336
+	{35, 7, 6, 338},            // 337
+	{35, 8, 6, 323},            // 338
+	{35, bright | 9, 0, 340},   // 339 S_SKEL_MISS1
+	{35, bright | 9, 10, 341},  // 340
+	{35, 10, 10, 342},          // 341
+	{35, 10, 10, 323},          // 342
+	{35, 11, 5, 344},           // 343 S_SKEL_PAIN
+	{35, 11, 5, 323},           // 344
+	{35, 11, 7, 346},           // 345 S_SKEL_DIE1
+	{35, 12, 7, 347},           // 346
+	{35, 13, 7, 348},           // 347
+	{35, 14, 7, 349},           // 348
+	{35, 15, 7, 350},           // 349
+	{35, 16, -1, 0},            // 350
+	{35, 16, 5, 352},           // 351 S_SKEL_RAISE1
+	{35, 15, 5, 353},           // 352
+	{35, 14, 5, 354},           // 353
+	{35, 13, 5, 355},           // 354
+	{35, 12, 5, 356},           // 355
+	{35, 11, 5, 323},           // 356
+	{36, bright | 0, 4, 358},   // 357 S_FATSHOT1
+	{36, bright | 1, 4, 357},   // 358
+	{22, bright | 1, 8, 360},   // 359 S_FATSHOTX1
+	{22, bright | 2, 6, 361},   // 360
+	{22, bright | 3, 4, 0},     // 361
+	{37, 0, 15, 363},           // 362 S_FATT_STND
+	{37, 1, 15, 362},           // 363
+	{37, 0, 4, 365},            // 364 S_FATT_RUN1
+	{37, 0, 4, 366},            // 365
+	{37, 1, 4, 367},            // 366
+	{37, 1, 4, 368},            // 367
+	{37, 2, 4, 369},            // 368
+	{37, 2, 4, 370},            // 369
+	{37, 3, 4, 371},            // 370
+	{37, 3, 4, 372},            // 371
+	{37, 4, 4, 373},            // 372
+	{37, 4, 4, 374},            // 373
+	{37, 5, 4, 375},            // 374
+	{37, 5, 4, 364},            // 375
+	{37, 6, 20, 377},           // 376 S_FATT_ATK1
+	{37, bright | 7, 10, 378},  // 377
+	{37, 8, 5, 379},            // 378
+	{37, 6, 5, 380},            // 379
+	{37, bright | 7, 10, 381},  // 380
+	{37, 8, 5, 382},            // 381
+	{37, 6, 5, 383},            // 382
+	{37, bright | 7, 10, 384},  // 383
+	{37, 8, 5, 385},            // 384
+	{37, 6, 5, 364},            // 385
+	{37, 9, 3, 387},            // 386 S_FATT_PAIN
+	{37, 9, 3, 364},            // 387
+	{37, 10, 6, 389},           // 388 S_FATT_DIE1
+	{37, 11, 6, 390},           // 389
+	{37, 12, 6, 391},           // 390
+	{37, 13, 6, 392},           // 391
+	{37, 14, 6, 393},           // 392
+	{37, 15, 6, 394},           // 393
+	{37, 16, 6, 395},           // 394
+	{37, 17, 6, 396},           // 395
+	{37, 18, 6, 397},           // 396
+	{37, 19, -1, 0},            // 397
+	{37, 17, 5, 399},           // 398 S_FATT_RAISE1
+	{37, 16, 5, 400},           // 399
+	{37, 15, 5, 401},           // 400
+	{37, 14, 5, 402},           // 401
+	{37, 13, 5, 403},           // 402
+	{37, 12, 5, 404},           // 403
+	{37, 11, 5, 405},           // 404
+	{37, 10, 5, 364},           // 405
+	{38, 0, 10, 407},           // 406 S_CPOS_STND
+	{38, 1, 10, 406},           // 407
+	{38, 0, 3, 409},            // 408 S_CPOS_RUN1
+	{38, 0, 3, 410},            // 409
+	{38, 1, 3, 411},            // 410
+	{38, 1, 3, 412},            // 411
+	{38, 2, 3, 413},            // 412
+	{38, 2, 3, 414},            // 413
+	{38, 3, 3, 415},            // 414
+	{38, 3, 3, 408},            // 415
+	{38, 4, 10, 417},           // 416 S_CPOS_ATK1
+	{38, bright | 5, 4, 418},   // 417
+	{38, bright | 4, 4, 419},   // 418
+	{38, 5, 1, 417},            // 419
+	{38, 6, 3, 421},            // 420 S_CPOS_PAIN
+	{38, 6, 3, 408},            // 421
+	{38, 7, 5, 423},            // 422 S_CPOS_DIE1
+	{38, 8, 5, 424},            // 423
+	{38, 9, 5, 425},            // 424
+	{38, 10, 5, 426},           // 425
+	{38, 11, 5, 427},           // 426
+	{38, 12, 5, 428},           // 427
+	{38, 13, -1, 0},            // 428
+	{38, 14, 5, 430},           // 429 S_CPOS_XDIE1
+	{38, 15, 5, 431},           // 430
+	{38, 16, 5, 432},           // 431
+	{38, 17, 5, 433},           // 432
+	{38, 18, 5, 434},           // 433
+	{38, 19, -1, 0},            // 434
+	{38, 13, 5, 436},           // 435 S_CPOS_RAISE1
+	{38, 12, 5, 437},           // 436
+	{38, 11, 5, 438},           // 437
+	{38, 10, 5, 439},           // 438
+	{38, 9, 5, 440},            // 439
+	{38, 8, 5, 441},            // 440
+	{38, 7, 5, 408},            // 441
+	{0, 0, 10, 443},            // 442 S_TROO_STND
+	{0, 1, 10, 442},            // 443
+	{0, 0, 3, 445},             // 444 S_TROO_RUN1
+	{0, 0, 3, 446},             // 445
+	{0, 1, 3, 447},             // 446
+	{0, 1, 3, 448},             // 447
+	{0, 2, 3, 449},             // 448
+	{0, 2, 3, 450},             // 449
+	{0, 3, 3, 451},             // 450
+	{0, 3, 3, 444},             // 451
+	{0, 4, 8, 453},             // 452 S_TROO_ATK1
+	{0, 5, 8, 454},             // 453
+	{0, 6, 6, 444},             // 454
+	{0, 7, 2, 456},             // 455 S_TROO_PAIN
+	{0, 7, 2, 444},             // 456
+	{0, 8, 8, 458},             // 457 S_TROO_DIE1
+	{0, 9, 8, 459},             // 458
+	{0, 10, 6, 460},            // 459
+	{0, 11, 6, 461},            // 460
+	{0, 12, -1, 0},             // 461
+	{0, 13, 5, 463},            // 462 S_TROO_XDIE1
+	{0, 14, 5, 464},            // 463
+	{0, 15, 5, 465},            // 464
+	{0, 16, 5, 466},            // 465
+	{0, 17, 5, 467},            // 466
+	{0, 18, 5, 468},            // 467
+	{0, 19, 5, 469},            // 468
+	{0, 20, -1, 0},             // 469
+	{0, 12, 8, 471},            // 470 S_TROO_RAISE1
+	{0, 11, 8, 472},            // 471
+	{0, 10, 6, 473},            // 472
+	{0, 9, 6, 474},             // 473
+	{0, 8, 6, 444},             // 474
+	{39, 0, 10, 476},           // 475 S_SARG_STND
+	{39, 1, 10, 475},           // 476
+	{39, 0, 2, 478},            // 477 S_SARG_RUN1
+	{39, 0, 2, 479},            // 478
+	{39, 1, 2, 480},            // 479
+	{39, 1, 2, 481},            // 480
+	{39, 2, 2, 482},            // 481
+	{39, 2, 2, 483},            // 482
+	{39, 3, 2, 484},            // 483
+	{39, 3, 2, 477},            // 484
+	{39, 4, 8, 486},            // 485 S_SARG_ATK1
+	{39, 5, 8, 487},            // 486
+	{39, 6, 8, 477},            // 487
+	{39, 7, 2, 489},            // 488 S_SARG_PAIN
+	{39, 7, 2, 477},            // 489
+	{39, 8, 8, 491},            // 490 S_SARG_DIE1
+	{39, 9, 8, 492},            // 491
+	{39, 10, 4, 493},           // 492
+	{39, 11, 4, 494},           // 493
+	{39, 12, 4, 495},           // 494
+	{39, 13, -1, 0},            // 495
+	{39, 13, 5, 497},           // 496 S_SARG_RAISE1
+	{39, 12, 5, 498},           // 497
+	{39, 11, 5, 499},           // 498
+	{39, 10, 5, 500},           // 499
+	{39, 9, 5, 501},            // 500
+	{39, 8, 5, 477},            // 501
+	{40, 0, 10, 502},           // 502 S_HEAD_STND
+	{40, 0, 3, 503},            // 503 S_HEAD_RUN1
+	{40, 1, 5, 505},            // 504 S_HEAD_ATK1
+	{40, 2, 5, 506},            // 505
+	{40, bright | 3, 5, 503},   // 506
+	{40, 4, 3, 508},            // 507 S_HEAD_PAIN
+	{40, 4, 3, 509},            // 508
+	{40, 5, 6, 503},            // 509
+	{40, 6, 8, 511},            // 510 S_HEAD_DIE1
+	{40, 7, 8, 512},            // 511
+	{40, 8, 8, 513},            // 512
+	{40, 9, 8, 514},            // 513
+	{40, 10, 8, 515},           // 514
+	{40, 11, -1, 0},            // 515
+	{40, 11, 8, 517},           // 516 S_HEAD_RAISE1
+	{40, 10, 8, 518},           // 517
+	{40, 9, 8, 519},            // 518
+	{40, 8, 8, 520},            // 519
+	{40, 7, 8, 521},            // 520
+	{40, 6, 8, 503},            // 521
+	{41, bright | 0, 4, 523},   // 522 S_BRBALL1
+	{41, bright | 1, 4, 522},   // 523
+	{41, bright | 2, 6, 525},   // 524 S_BRBALLX1
+	{41, bright | 3, 6, 526},   // 525
+	{41, bright | 4, 6, 0},     // 526
+	{42, 0, 10, 528},           // 527 S_BOSS_STND
+	{42, 1, 10, 527},           // 528
+	{42, 0, 3, 530},            // 529 S_BOSS_RUN1
+	{42, 0, 3, 531},            // 530
+	{42, 1, 3, 532},            // 531
+	{42, 1, 3, 533},            // 532
+	{42, 2, 3, 534},            // 533
+	{42, 2, 3, 535},            // 534
+	{42, 3, 3, 536},            // 535
+	{42, 3, 3, 529},            // 536
+	{42, 4, 8, 538},            // 537 S_BOSS_ATK1
+	{42, 5, 8, 539},            // 538
+	{42, 6, 8, 529},            // 539
+	{42, 7, 2, 541},            // 540 S_BOSS_PAIN
+	{42, 7, 2, 529},            // 541
+	{42, 8, 8, 543},            // 542 S_BOSS_DIE1
+	{42, 9, 8, 544},            // 543
+	{42, 10, 8, 545},           // 544
+	{42, 11, 8, 546},           // 545
+	{42, 12, 8, 547},           // 546
+	{42, 13, 8, 548},           // 547
+	{42, 14, -1, 0},            // 548
+	{42, 14, 8, 550},           // 549 S_BOSS_RAISE1
+	{42, 13, 8, 551},           // 550
+	{42, 12, 8, 552},           // 551
+	{42, 11, 8, 553},           // 552
+	{42, 10, 8, 554},           // 553
+	{42, 9, 8, 555},            // 554
+	{42, 8, 8, 529},            // 555
+	{43, 0, 10, 557},           // 556 S_BOS2_STND
+	{43, 1, 10, 556},           // 557
+	{43, 0, 3, 559},            // 558 S_BOS2_RUN1
+	{43, 0, 3, 560},            // 559
+	{43, 1, 3, 561},            // 560
+	{43, 1, 3, 562},            // 561
+	{43, 2, 3, 563},            // 562
+	{43, 2, 3, 564},            // 563
+	{43, 3, 3, 565},            // 564
+	{43, 3, 3, 558},            // 565
+	{43, 4, 8, 567},            // 566 S_BOS2_ATK1
+	{43, 5, 8, 568},            // 567
+	{43, 6, 8, 558},            // 568
+	{43, 7, 2, 570},            // 569 S_BOS2_PAIN
+	{43, 7, 2, 558},            // 570
+	{43, 8, 8, 572},            // 571 S_BOS2_DIE1
+	{43, 9, 8, 573},            // 572
+	{43, 10, 8, 574},           // 573
+	{43, 11, 8, 575},           // 574
+	{43, 12, 8, 576},           // 575
+	{43, 13, 8, 577},           // 576
+	{43, 14, -1, 0},            // 577
+	{43, 14, 8, 579},           // 578 S_BOS2_RAISE1
+	{43, 13, 8, 580},           // 579
+	{43, 12, 8, 581},           // 580
+	{43, 11, 8, 582},           // 581
+	{43, 10, 8, 583},           // 582
+	{43, 9, 8, 584},            // 583
+	{43, 8, 8, 558},            // 584
+	{44, bright | 0, 10, 586},  // 585 S_SKULL_STND
+	{44, bright | 1, 10, 585},  // 586
+	{44, bright | 0, 6, 588},   // 587 S_SKULL_RUN1
+	{44, bright | 1, 6, 587},   // 588
+	{44, bright | 2, 10, 590},  // 589 S_SKULL_ATK1
+	{44, bright | 3, 4, 591},   // 590
+	{44, bright | 2, 4, 592},   // 591
+	{44, bright | 3, 4, 591},   // 592
+	{44, bright | 4, 3, 594},   // 593 S_SKULL_PAIN
+	{44, bright | 4, 3, 587},   // 594
+	{44, bright | 5, 6, 596},   // 595 S_SKULL_DIE1
+	{44, bright | 6, 6, 597},   // 596
+	{44, bright | 7, 6, 598},   // 597
+	{44, bright | 8, 6, 599},   // 598
+	{44, 9, 6, 600},            // 599
+	{44, 10, 6, 0},             // 600
+	{45, 0, 10, 602},           // 601 S_SPID_STND
+	{45, 1, 10, 601},           // 602
+	{45, 0, 3, 604},            // 603 S_SPID_RUN1
+	{45, 0, 3, 605},            // 604
+	{45, 1, 3, 606},            // 605
+	{45, 1, 3, 607},            // 606
+	{45, 2, 3, 608},            // 607
+	{45, 2, 3, 609},            // 608
+	{45, 3, 3, 610},            // 609
+	{45, 3, 3, 611},            // 610
+	{45, 4, 3, 612},            // 611
+	{45, 4, 3, 613},            // 612
+	{45, 5, 3, 614},            // 613
+	{45, 5, 3, 603},            // 614
+	{45, bright | 0, 20, 616},  // 615 S_SPID_ATK1
+	{45, bright | 6, 4, 617},   // 616
+	{45, bright | 7, 4, 618},   // 617
+	{45, bright | 7, 1, 616},   // 618
+	{45, 8, 3, 620},            // 619 S_SPID_PAIN
+	{45, 8, 3, 603},            // 620
+	{45, 9, 20, 622},           // 621 S_SPID_DIE1
+	{45, 10, 10, 623},          // 622
+	{45, 11, 10, 624},          // 623
+	{45, 12, 10, 625},          // 624
+	{45, 13, 10, 626},          // 625
+	{45, 14, 10, 627},          // 626
+	{45, 15, 10, 628},          // 627
+	{45, 16, 10, 629},          // 628
+	{45, 17, 10, 630},          // 629
+	{45, 18, 30, 631},          // 630
+	{45, 18, -1, 0},            // 631
+	{46, 0, 10, 633},           // 632 S_BSPI_STND
+	{46, 1, 10, 632},           // 633
+	{46, 0, 20, 635},           // 634 S_BSPI_SIGHT
+	{46, 0, 3, 636},            // 635 S_BSPI_RUN1
+	{46, 0, 3, 637},            // 636
+	{46, 1, 3, 638},            // 637
+	{46, 1, 3, 639},            // 638
+	{46, 2, 3, 640},            // 639
+	{46, 2, 3, 641},            // 640
+	{46, 3, 3, 642},            // 641
+	{46, 3, 3, 643},            // 642
+	{46, 4, 3, 644},            // 643
+	{46, 4, 3, 645},            // 644
+	{46, 5, 3, 646},            // 645
+	{46, 5, 3, 635},            // 646
+	{46, bright | 0, 20, 648},  // 647 S_BSPI_ATK1
+	{46, bright | 6, 4, 649},   // 648
+	{46, bright | 7, 4, 650},   // 649
+	{46, bright | 7, 1, 648},   // 650
+	{46, 8, 3, 652},            // 651 S_BSPI_PAIN
+	{46, 8, 3, 635},            // 652
+	{46, 9, 20, 654},           // 653 S_BSPI_DIE1
+	{46, 10, 7, 655},           // 654
+	{46, 11, 7, 656},           // 655
+	{46, 12, 7, 657},           // 656
+	{46, 13, 7, 658},           // 657
+	{46, 14, 7, 659},           // 658
+	{46, 15, -1, 0},            // 659
+	{46, 15, 5, 661},           // 660 S_BSPI_RAISE1
+	{46, 14, 5, 662},           // 661
+	{46, 13, 5, 663},           // 662
+	{46, 12, 5, 664},           // 663
+	{46, 11, 5, 665},           // 664
+	{46, 10, 5, 666},           // 665
+	{46, 9, 5, 635},            // 666
+	{47, bright | 0, 5, 668},   // 667 S_ARACH_PLAZ
+	{47, bright | 1, 5, 667},   // 668
+	{48, bright | 0, 5, 670},   // 669 S_ARACH_PLEX
+	{48, bright | 1, 5, 671},   // 670
+	{48, bright | 2, 5, 672},   // 671
+	{48, bright | 3, 5, 673},   // 672
+	{48, bright | 4, 5, 0},     // 673
+	{49, 0, 10, 675},           // 674 S_CYBER_STND
+	{49, 1, 10, 674},           // 675
+	{49, 0, 3, 677},            // 676 S_CYBER_RUN1
+	{49, 0, 3, 678},            // 677
+	{49, 1, 3, 679},            // 678
+	{49, 1, 3, 680},            // 679
+	{49, 2, 3, 681},            // 680
+	{49, 2, 3, 682},            // 681
+	{49, 3, 3, 683},            // 682
+	{49, 3, 3, 676},            // 683
+	{49, 4, 6, 685},            // 684 S_CYBER_ATK1
+	{49, 5, 12, 686},           // 685
+	{49, 4, 12, 687},           // 686
+	{49, 5, 12, 688},           // 687
+	{49, 4, 12, 689},           // 688
+	{49, 5, 12, 676},           // 689
+	{49, 6, 10, 676},           // 690 S_CYBER_PAIN
+	{49, 7, 10, 692},           // 691 S_CYBER_DIE1
+	{49, 8, 10, 693},           // 692
+	{49, 9, 10, 694},           // 693
+	{49, 10, 10, 695},          // 694
+	{49, 11, 10, 696},          // 695
+	{49, 12, 10, 697},          // 696
+	{49, 13, 10, 698},          // 697
+	{49, 14, 10, 699},          // 698
+	{49, 15, 30, 700},          // 699
+	{49, 15, -1, 0},            // 700
+	{50, 0, 10, 701},           // 701 S_PAIN_STND
+	{50, 0, 3, 703},            // 702 S_PAIN_RUN1
+	{50, 0, 3, 704},            // 703
+	{50, 1, 3, 705},            // 704
+	{50, 1, 3, 706},            // 705
+	{50, 2, 3, 707},            // 706
+	{50, 2, 3, 702},            // 707
+	{50, 3, 5, 709},            // 708 S_PAIN_ATK1
+	{50, 4, 5, 710},            // 709
+	{50, bright | 5, 5, 711},   // 710
+	{50, bright | 5, 0, 702},   // 711
+	{50, 6, 6, 713},            // 712 S_PAIN_PAIN
+	{50, 6, 6, 702},            // 713
+	{50, bright | 7, 8, 715},   // 714 S_PAIN_DIE1
+	{50, bright | 8, 8, 716},   // 715
+	{50, bright | 9, 8, 717},   // 716
+	{50, bright | 10, 8, 718},  // 717
+	{50, bright | 11, 8, 719},  // 718
+	{50, bright | 12, 8, 0},    // 719
+	{50, 12, 8, 721},           // 720 S_PAIN_RAISE1
+	{50, 11, 8, 722},           // 721
+	{50, 10, 8, 723},           // 722
+	{50, 9, 8, 724},            // 723
+	{50, 8, 8, 725},            // 724
+	{50, 7, 8, 702},            // 725
+	{51, 0, 10, 727},           // 726 S_SSWV_STND
+	{51, 1, 10, 726},           // 727
+	{51, 0, 3, 729},            // 728 S_SSWV_RUN1
+	{51, 0, 3, 730},            // 729
+	{51, 1, 3, 731},            // 730
+	{51, 1, 3, 732},            // 731
+	{51, 2, 3, 733},            // 732
+	{51, 2, 3, 734},            // 733
+	{51, 3, 3, 735},            // 734
+	{51, 3, 3, 728},            // 735
+	{51, 4, 10, 737},           // 736 S_SSWV_ATK1
+	{51, 5, 10, 738},           // 737
+	{51, bright | 6, 4, 739},   // 738
+	{51, 5, 6, 740},            // 739
+	{51, bright | 6, 4, 741},   // 740
+	{51, 5, 1, 737},            // 741
+	{51, 7, 3, 743},            // 742 S_SSWV_PAIN
+	{51, 7, 3, 728},            // 743
+	{51, 8, 5, 745},            // 744 S_SSWV_DIE1
+	{51, 9, 5, 746},            // 745
+	{51, 10, 5, 747},           // 746
+	{51, 11, 5, 748},           // 747
+	{51, 12, -1, 0},            // 748
+	{51, 13, 5, 750},           // 749 S_SSWV_XDIE1
+	{51, 14, 5, 751},           // 750
+	{51, 15, 5, 752},           // 751
+	{51, 16, 5, 753},           // 752
+	{51, 17, 5, 754},           // 753
+	{51, 18, 5, 755},           // 754
+	{51, 19, 5, 756},           // 755
+	{51, 20, 5, 757},           // 756
+	{51, 21, -1, 0},            // 757
+	{51, 12, 5, 759},           // 758 S_SSWV_RAISE1
+	{51, 11, 5, 760},           // 759
+	{51, 10, 5, 761},           // 760
+	{51, 9, 5, 762},            // 761
+	{51, 8, 5, 728},            // 762
+	{52, 0, -1, 763},           // 763 S_KEENSTND
+	{52, 0, 6, 765},            // 764 S_COMMKEEN
+	{52, 1, 6, 766},            // 765
+	{52, 2, 6, 767},            // 766
+	{52, 3, 6, 768},            // 767
+	{52, 4, 6, 769},            // 768
+	{52, 5, 6, 770},            // 769
+	{52, 6, 6, 771},            // 770
+	{52, 7, 6, 772},            // 771
+	{52, 8, 6, 773},            // 772
+	{52, 9, 6, 774},            // 773
+	{52, 10, 6, 775},           // 774
+	{52, 11, -1, 0},            // 775
+	{52, 12, 4, 777},           // 776 S_KEENPAIN
+	{52, 12, 8, 763},           // 777
+	{53, 0, -1, 0},             // 778 S_BRAIN
+	{53, 1, 36, 778},           // 779 S_BRAIN_PAIN
+	{53, 0, 100, 781},          // 780 S_BRAIN_DIE1
+	{53, 0, 10, 782},           // 781
+	{53, 0, 10, 783},           // 782
+	{53, 0, -1, 0},             // 783
+	{51, 0, 10, 784},           // 784 S_BRAINEYE
+	{51, 0, 181, 786},          // 785 S_BRAINEYESEE
+	{51, 0, 150, 786},          // 786 S_BRAINEYE1
+	{54, bright | 0, 3, 788},   // 787 S_SPAWN1
+	{54, bright | 1, 3, 789},   // 788
+	{54, bright | 2, 3, 790},   // 789
+	{54, bright | 3, 3, 787},   // 790
+	{32, bright | 0, 4, 792},   // 791 S_SPAWNFIRE1
+	{32, bright | 1, 4, 793},   // 792
+	{32, bright | 2, 4, 794},   // 793
+	{32, bright | 3, 4, 795},   // 794
+	{32, bright | 4, 4, 796},   // 795
+	{32, bright | 5, 4, 797},   // 796
+	{32, bright | 6, 4, 798},   // 797
+	{32, bright | 7, 4, 0},     // 798
+	{22, bright | 1, 10, 800},  // 799 S_BRAINEXPLODE1
+	{22, bright | 2, 10, 801},  // 800
+	{22, bright | 3, 10, 0},    // 801
+	{55, 0, 6, 803},            // 802 S_ARM1
+	{55, bright | 1, 7, 802},   // 803
+	{56, 0, 6, 805},            // 804 S_ARM2
+	{56, bright | 1, 6, 804},   // 805
+	{57, 0, 6, 807},            // 806 S_BAR1
+	{57, 1, 6, 806},            // 807
+	{58, bright | 0, 5, 809},   // 808 S_BEXP
+	{58, bright | 1, 5, 810},   // 809
+	{58, bright | 2, 5, 811},   // 810
+	{58, bright | 3, 10, 812},  // 811
+	{58, bright | 4, 10, 0},    // 812
+	{59, bright | 0, 4, 814},   // 813 S_BBAR1
+	{59, bright | 1, 4, 815},   // 814
+	{59, bright | 2, 4, 813},   // 815
+	{60, 0, 6, 817},            // 816 S_BON1
+	{60, 1, 6, 818},            // 817
+	{60, 2, 6, 819},            // 818
+	{60, 3, 6, 820},            // 819
+	{60, 2, 6, 821},            // 820
+	{60, 1, 6, 816},            // 821
+	{61, 0, 6, 823},            // 822 S_BON2
+	{61, 1, 6, 824},            // 823
+	{61, 2, 6, 825},            // 824
+	{61, 3, 6, 826},            // 825
+	{61, 2, 6, 827},            // 826
+	{61, 1, 6, 822},            // 827
+	{62, 0, 10, 829},           // 828 S_BKEY
+	{62, bright | 1, 10, 828},  // 829
+	{63, 0, 10, 831},           // 830 S_RKEY
+	{63, bright | 1, 10, 830},  // 831
+	{64, 0, 10, 833},           // 832 S_YKEY
+	{64, bright | 1, 10, 832},  // 833
+	{65, 0, 10, 835},           // 834 S_BSKULL
+	{65, bright | 1, 10, 834},  // 835
+	{66, 0, 10, 837},           // 836 S_RSKULL
+	{66, bright | 1, 10, 836},  // 837
+	{67, 0, 10, 839},           // 838 S_YSKULL
+	{67, bright | 1, 10, 838},  // 839
+	{68, 0, -1, 0},             // 840 S_STIM
+	{69, 0, -1, 0},             // 841 S_MEDI
+	{70, bright | 0, 6, 843},   // 842 S_SOUL
+	{70, bright | 1, 6, 844},   // 843
+	{70, bright | 2, 6, 845},   // 844
+	{70, bright | 3, 6, 846},   // 845
+	{70, bright | 2, 6, 847},   // 846
+	{70, bright | 1, 6, 842},   // 847
+	{71, bright | 0, 6, 849},   // 848 S_PINV
+	{71, bright | 1, 6, 850},   // 849
+	{71, bright | 2, 6, 851},   // 850
+	{71, bright | 3, 6, 848},   // 851
+	{72, bright | 0, -1, 0},    // 852 S_PSTR
+	{73, bright | 0, 6, 854},   // 853 S_PINS
+	{73, bright | 1, 6, 855},   // 854
+	{73, bright | 2, 6, 856},   // 855
+	{73, bright | 3, 6, 853},   // 856
+	{74, bright | 0, 6, 858},   // 857 S_MEGA
+	{74, bright | 1, 6, 859},   // 858
+	{74, bright | 2, 6, 860},   // 859
+	{74, bright | 3, 6, 857},   // 860
+	{75, bright | 0, -1, 0},    // 861 S_SUIT
+	{76, bright | 0, 6, 863},   // 862 S_PMAP
+	{76, bright | 1, 6, 864},   // 863
+	{76, bright | 2, 6, 865},   // 864
+	{76, bright | 3, 6, 866},   // 865
+	{76, bright | 2, 6, 867},   // 866
+	{76, bright | 1, 6, 862},   // 867
+	{77, bright | 0, 6, 869},   // 868 S_PVIS
+	{77, 1, 6, 868},            // 869
+	{78, 0, -1, 0},             // 870 S_CLIP
+	{79, 0, -1, 0},             // 871 S_AMMO
+	{80, 0, -1, 0},             // 872 S_ROCK
+	{81, 0, -1, 0},             // 873 S_BROK
+	{82, 0, -1, 0},             // 874 S_CELL
+	{83, 0, -1, 0},             // 875 S_CELP
+	{84, 0, -1, 0},             // 876 S_SHEL
+	{85, 0, -1, 0},             // 877 S_SBOX
+	{86, 0, -1, 0},             // 878 S_BPAK
+	{87, 0, -1, 0},             // 879 S_BFUG
+	{88, 0, -1, 0},             // 880 S_MGUN
+	{89, 0, -1, 0},             // 881 S_CSAW
+	{90, 0, -1, 0},             // 882 S_LAUN
+	{91, 0, -1, 0},             // 883 S_PLAS
+	{92, 0, -1, 0},             // 884 S_SHOT
+	{93, 0, -1, 0},             // 885 S_SHOT2
+	{94, bright | 0, -1, 0},    // 886 S_COLU
+	{95, 0, -1, 0},             // 887 S_STALAG
+	{96, 0, 10, 889},           // 888 S_BLOODYTWITCH
+	{96, 1, 15, 890},           // 889
+	{96, 2, 8, 891},            // 890
+	{96, 1, 6, 888},            // 891
+	{28, 13, -1, 0},            // 892 S_DEADTORSO
+	{28, 18, -1, 0},            // 893 S_DEADBOTTOM
+	{97, 0, -1, 0},             // 894 S_HEADSONSTICK
+	{98, 0, -1, 0},             // 895 S_GIBS
+	{99, 0, -1, 0},             // 896 S_HEADONASTICK
+	{100, bright | 0, 6, 898},  // 897 S_HEADCANDLES
+	{100, bright | 1, 6, 897},  // 898
+	{101, 0, -1, 0},            // 899 S_DEADSTICK
+	{102, 0, 6, 901},           // 900 S_LIVESTICK
+	{102, 1, 8, 900},           // 901
+	{103, 0, -1, 0},            // 902 S_MEAT2
+	{104, 0, -1, 0},            // 903 S_MEAT3
+	{105, 0, -1, 0},            // 904 S_MEAT4
+	{106, 0, -1, 0},            // 905 S_MEAT5
+	{107, 0, -1, 0},            // 906 S_STALAGTITE
+	{108, 0, -1, 0},            // 907 S_TALLGRNCOL
+	{109, 0, -1, 0},            // 908 S_SHRTGRNCOL
+	{110, 0, -1, 0},            // 909 S_TALLREDCOL
+	{111, 0, -1, 0},            // 910 S_SHRTREDCOL
+	{112, bright | 0, -1, 0},   // 911 S_CANDLESTIK
+	{113, bright | 0, -1, 0},   // 912 S_CANDELABRA
+	{114, 0, -1, 0},            // 913 S_SKULLCOL
+	{115, 0, -1, 0},            // 914 S_TORCHTREE
+	{116, 0, -1, 0},            // 915 S_BIGTREE
+	{117, 0, -1, 0},            // 916 S_TECHPILLAR
+	{118, bright | 0, 6, 918},  // 917 S_EVILEYE
+	{118, bright | 1, 6, 919},  // 918
+	{118, bright | 2, 6, 920},  // 919
+	{118, bright | 1, 6, 917},  // 920
+	{119, bright | 0, 6, 922},  // 921 S_FLOATSKULL
+	{119, bright | 1, 6, 923},  // 922
+	{119, bright | 2, 6, 921},  // 923
+	{120, 0, 14, 925},          // 924 S_HEARTCOL
+	{120, 1, 14, 924},          // 925
+	{121, bright | 0, 4, 927},  // 926 S_BLUETORCH
+	{121, bright | 1, 4, 928},  // 927
+	{121, bright | 2, 4, 929},  // 928
+	{121, bright | 3, 4, 926},  // 929
+	{122, bright | 0, 4, 931},  // 930 S_GREENTORCH
+	{122, bright | 1, 4, 932},  // 931
+	{122, bright | 2, 4, 933},  // 932
+	{122, bright | 3, 4, 930},  // 933
+	{123, bright | 0, 4, 935},  // 934 S_REDTORCH
+	{123, bright | 1, 4, 936},  // 935
+	{123, bright | 2, 4, 937},  // 936
+	{123, bright | 3, 4, 934},  // 937
+	{124, bright | 0, 4, 939},  // 938 S_BTORCHSHRT
+	{124, bright | 1, 4, 940},  // 939
+	{124, bright | 2, 4, 941},  // 940
+	{124, bright | 3, 4, 938},  // 941
+	{125, bright | 0, 4, 943},  // 942 S_GTORCHSHRT
+	{125, bright | 1, 4, 944},  // 943
+	{125, bright | 2, 4, 945},  // 944
+	{125, bright | 3, 4, 942},  // 945
+	{126, bright | 0, 4, 947},  // 946 S_RTORCHSHRT
+	{126, bright | 1, 4, 948},  // 947
+	{126, bright | 2, 4, 949},  // 948
+	{126, bright | 3, 4, 946},  // 949
+	{127, 0, -1, 0},            // 950 S_HANGNOGUTS
+	{128, 0, -1, 0},            // 951 S_HANGBNOBRAIN
+	{129, 0, -1, 0},            // 952 S_HANGTLOOKDN
+	{130, 0, -1, 0},            // 953 S_HANGTSKULL
+	{131, 0, -1, 0},            // 954 S_HANGTLOOKUP
+	{132, 0, -1, 0},            // 955 S_HANGTNOBRAIN
+	{133, 0, -1, 0},            // 956 S_COLONGIBS
+	{134, 0, -1, 0},            // 957 S_SMALLPOOL
+	{135, 0, -1, 0},            // 958 S_BRAINSTEM
+	{136, bright | 0, 4, 960},  // 959 S_TECHLAMP
+	{136, bright | 1, 4, 961},  // 960
+	{136, bright | 2, 4, 962},  // 961
+	{136, bright | 3, 4, 959},  // 962
+	{137, bright | 0, 4, 964},  // 963 S_TECH2LAMP
+	{137, bright | 1, 4, 965},  // 964
+	{137, bright | 2, 4, 966},  // 965
+	{137, bright | 3, 4, 963},  // 966
+}
+
+// vanillaThings is the mobjinfo[] table of the Doom II v1.9 executable,
+// as far as sprites are concerned, under the class names ZDoom gives the
+// things; DEHACKED thing numbers count from 1 into it.
+var vanillaThings = []InfoThing{
+	{"DoomPlayer", -1, [NumLabels]int{149, 150, 156, 0, 154, 158, 165, 0}},      // 1
+	{"ZombieMan", 3004, [NumLabels]int{174, 176, 187, 0, 184, 189, 194, 203}},   // 2
+	{"ShotgunGuy", 9, [NumLabels]int{207, 209, 220, 0, 217, 222, 227, 236}},     // 3
+	{"Archvile", 64, [NumLabels]int{241, 243, 269, 0, 255, 271, 0, 0}},          // 4
+	{"ArchvileFire", -1, [NumLabels]int{281, 0, 0, 0, 0, 0, 0, 0}},              // 5
+	{"Revenant", 66, [NumLabels]int{321, 323, 343, 335, 339, 345, 0, 351}},      // 6
+	{"RevenantTracer", -1, [NumLabels]int{316, 0, 0, 0, 0, 318, 0, 0}},          // 7
+	{"RevenantTracerSmoke", -1, [NumLabels]int{311, 0, 0, 0, 0, 0, 0, 0}},       // 8
+	{"Fatso", 67, [NumLabels]int{362, 364, 386, 0, 376, 388, 0, 398}},           // 9
+	{"FatShot", -1, [NumLabels]int{357, 0, 0, 0, 0, 359, 0, 0}},                 // 10
+	{"ChaingunGuy", 65, [NumLabels]int{406, 408, 420, 0, 416, 422, 429, 435}},   // 11
+	{"DoomImp", 3001, [NumLabels]int{442, 444, 455, 452, 452, 457, 462, 470}},   // 12
+	{"Demon", 3002, [NumLabels]int{475, 477, 488, 485, 0, 490, 0, 496}},         // 13
+	{"Spectre", 58, [NumLabels]int{475, 477, 488, 485, 0, 490, 0, 496}},         // 14
+	{"Cacodemon", 3005, [NumLabels]int{502, 503, 507, 0, 504, 510, 0, 516}},     // 15
+	{"BaronOfHell", 3003, [NumLabels]int{527, 529, 540, 537, 537, 542, 0, 549}}, // 16
+	{"BaronBall", -1, [NumLabels]int{522, 0, 0, 0, 0, 524, 0, 0}},               // 17
+	{"HellKnight", 69, [NumLabels]int{556, 558, 569, 566, 566, 571, 0, 578}},    // 18
+	{"LostSoul", 3006, [NumLabels]int{585, 587, 593, 0, 589, 595, 0, 0}},        // 19
+	{"SpiderMastermind", 7, [NumLabels]int{601, 603, 619, 0, 615, 621, 0, 0}},   // 20
+	{"Arachnotron", 68, [NumLabels]int{632, 634, 651, 0, 647, 653, 0, 660}},     // 21
+	{"Cyberdemon", 16, [NumLabels]int{674, 676, 690, 0, 684, 691, 0, 0}},        // 22
+	{"PainElemental", 71, [NumLabels]int{701, 702, 712, 0, 708, 714, 0, 720}},   // 23
+	{"WolfensteinSS", 84, [NumLabels]int{726, 728, 742, 0, 736, 744, 749, 758}}, // 24
+	{"CommanderKeen", 72, [NumLabels]int{763, 0, 776, 0, 0, 764, 0, 0}},         // 25
+	{"BossBrain", 88, [NumLabels]int{778, 0, 779, 0, 0, 780, 0, 0}},             // 26
+	{"BossEye", 89, [NumLabels]int{784, 785, 0, 0, 0, 0, 0, 0}},                 // 27
+	{"BossTarget", 87, [NumLabels]int{0, 0, 0, 0, 0, 0, 0, 0}},                  // 28
+	{"SpawnShot", -1, [NumLabels]int{787, 0, 0, 0, 0, 0, 0, 0}},                 // 29
+	{"SpawnFire", -1, [NumLabels]int{791, 0, 0, 0, 0, 0, 0, 0}},                 // 30
+	{"ExplosiveBarrel", 2035, [NumLabels]int{806, 0, 0, 0, 0, 808, 0, 0}},       // 31
+	{"DoomImpBall", -1, [NumLabels]int{97, 0, 0, 0, 0, 99, 0, 0}},               // 32
+	{"CacodemonBall", -1, [NumLabels]int{102, 0, 0, 0, 0, 104, 0, 0}},           // 33
+	{"Rocket", -1, [NumLabels]int{114, 0, 0, 0, 0, 127, 0, 0}},                  // 34
+	{"PlasmaBall", -1, [NumLabels]int{107, 0, 0, 0, 0, 109, 0, 0}},              // 35
+	{"BFGBall", -1, [NumLabels]int{115, 0, 0, 0, 0, 117, 0, 0}},                 // 36
+	{"ArachnotronPlasma", -1, [NumLabels]int{667, 0, 0, 0, 0, 669, 0, 0}},       // 37
+	{"BulletPuff", -1, [NumLabels]int{93, 0, 0, 0, 0, 0, 0, 0}},                 // 38
+	{"Blood", -1, [NumLabels]int{90, 0, 0, 0, 0, 0, 0, 0}},                      // 39
+	{"TeleportFog", -1, [NumLabels]int{130, 0, 0, 0, 0, 0, 0, 0}},               // 40
+	{"ItemFog", -1, [NumLabels]int{142, 0, 0, 0, 0, 0, 0, 0}},                   // 41
+	{"TeleportDest", 14, [NumLabels]int{0, 0, 0, 0, 0, 0, 0, 0}},                // 42
+	{"BFGExtra", -1, [NumLabels]int{123, 0, 0, 0, 0, 0, 0, 0}},                  // 43
+	{"GreenArmor", 2018, [NumLabels]int{802, 0, 0, 0, 0, 0, 0, 0}},              // 44
+	{"BlueArmor", 2019, [NumLabels]int{804, 0, 0, 0, 0, 0, 0, 0}},               // 45
+	{"HealthBonus", 2014, [NumLabels]int{816, 0, 0, 0, 0, 0, 0, 0}},             // 46
+	{"ArmorBonus", 2015, [NumLabels]int{822, 0, 0, 0, 0, 0, 0, 0}},              // 47
+	{"BlueCard", 5, [NumLabels]int{828, 0, 0, 0, 0, 0, 0, 0}},                   // 48
+	{"RedCard", 13, [NumLabels]int{830, 0, 0, 0, 0, 0, 0, 0}},                   // 49
+	{"YellowCard", 6, [NumLabels]int{832, 0, 0, 0, 0, 0, 0, 0}},                 // 50
+	{"YellowSkull", 39, [NumLabels]int{838, 0, 0, 0, 0, 0, 0, 0}},               // 51
+	{"RedSkull", 38, [NumLabels]int{836, 0, 0, 0, 0, 0, 0, 0}},                  // 52
+	{"BlueSkull", 40, [NumLabels]int{834, 0, 0, 0, 0, 0, 0, 0}},                 // 53
+	{"Stimpack", 2011, [NumLabels]int{840, 0, 0, 0, 0, 0, 0, 0}},                // 54
+	{"Medikit", 2012, [NumLabels]int{841, 0, 0, 0, 0, 0, 0, 0}},                 // 55
+	{"Soulsphere", 2013, [NumLabels]int{842, 0, 0, 0, 0, 0, 0, 0}},              // 56
+	{"InvulnerabilitySphere", 2022, [NumLabels]int{848, 0, 0, 0, 0, 0, 0, 0}},   // 57
+	{"Berserk", 2023, [NumLabels]int{852, 0, 0, 0, 0, 0, 0, 0}},                 // 58
+	{"BlurSphere", 2024, [NumLabels]int{853, 0, 0, 0, 0, 0, 0, 0}},              // 59
+	{"RadSuit", 2025, [NumLabels]int{861, 0, 0, 0, 0, 0, 0, 0}},                 // 60
+	{"Allmap", 2026, [NumLabels]int{862, 0, 0, 0, 0, 0, 0, 0}},                  // 61
+	{"Infrared", 2045, [NumLabels]int{868, 0, 0, 0, 0, 0, 0, 0}},                // 62
+	{"Megasphere", 83, [NumLabels]int{857, 0, 0, 0, 0, 0, 0, 0}},                // 63
+	{"Clip", 2007, [NumLabels]int{870, 0, 0, 0, 0, 0, 0, 0}},                    // 64
+	{"ClipBox", 2048, [NumLabels]int{871, 0, 0, 0, 0, 0, 0, 0}},                 // 65
+	{"RocketAmmo", 2010, [NumLabels]int{872, 0, 0, 0, 0, 0, 0, 0}},              // 66
+	{"RocketBox", 2046, [NumLabels]int{873, 0, 0, 0, 0, 0, 0, 0}},               // 67
+	{"Cell", 2047, [NumLabels]int{874, 0, 0, 0, 0, 0, 0, 0}},                    // 68
+	{"CellPack", 17, [NumLabels]int{875, 0, 0, 0, 0, 0, 0, 0}},                  // 69
+	{"Shell", 2008, [NumLabels]int{876, 0, 0, 0, 0, 0, 0, 0}},                   // 70
+	{"ShellBox", 2049, [NumLabels]int{877, 0, 0, 0, 0, 0, 0, 0}},                // 71
+	{"Backpack", 8, [NumLabels]int{878, 0, 0, 0, 0, 0, 0, 0}},                   // 72
+	{"BFG9000", 2006, [NumLabels]int{879, 0, 0, 0, 0, 0, 0, 0}},                 // 73
+	{"Chaingun", 2002, [NumLabels]int{880, 0, 0, 0, 0, 0, 0, 0}},                // 74
+	{"Chainsaw", 2005, [NumLabels]int{881, 0, 0, 0, 0, 0, 0, 0}},                // 75
+	{"RocketLauncher", 2003, [NumLabels]int{882, 0, 0, 0, 0, 0, 0, 0}},          // 76
+	{"PlasmaRifle", 2004, [NumLabels]int{883, 0, 0, 0, 0, 0, 0, 0}},             // 77
+	{"Shotgun", 2001, [NumLabels]int{884, 0, 0, 0, 0, 0, 0, 0}},                 // 78
+	{"SuperShotgun", 82, [NumLabels]int{885, 0, 0, 0, 0, 0, 0, 0}},              // 79
+	{"TechLamp", 85, [NumLabels]int{959, 0, 0, 0, 0, 0, 0, 0}},                  // 80
+	{"TechLamp2", 86, [NumLabels]int{963, 0, 0, 0, 0, 0, 0, 0}},                 // 81
+	{"Column", 2028, [NumLabels]int{886, 0, 0, 0, 0, 0, 0, 0}},                  // 82
+	{"TallGreenColumn", 30, [NumLabels]int{907, 0, 0, 0, 0, 0, 0, 0}},           // 83
+	{"ShortGreenColumn", 31, [NumLabels]int{908, 0, 0, 0, 0, 0, 0, 0}},          // 84
+	{"TallRedColumn", 32, [NumLabels]int{909, 0, 0, 0, 0, 0, 0, 0}},             // 85
+	{"ShortRedColumn", 33, [NumLabels]int{910, 0, 0, 0, 0, 0, 0, 0}},            // 86
+	{"SkullColumn", 37, [NumLabels]int{913, 0, 0, 0, 0, 0, 0, 0}},               // 87
+	{"HeartColumn", 36, [NumLabels]int{924, 0, 0, 0, 0, 0, 0, 0}},               // 88
+	{"EvilEye", 41, [NumLabels]int{917, 0, 0, 0, 0, 0, 0, 0}},                   // 89
+	{"FloatingSkull", 42, [NumLabels]int{921, 0, 0, 0, 0, 0, 0, 0}},             // 90
+	{"TorchTree", 43, [NumLabels]int{914, 0, 0, 0, 0, 0, 0, 0}},                 // 91
+	{"BlueTorch", 44, [NumLabels]int{926, 0, 0, 0, 0, 0, 0, 0}},                 // 92
+	{"GreenTorch", 45, [NumLabels]int{930, 0, 0, 0, 0, 0, 0, 0}},                // 93
+	{"RedTorch", 46, [NumLabels]int{934, 0, 0, 0, 0, 0, 0, 0}},                  // 94
+	{"ShortBlueTorch", 55, [NumLabels]int{938, 0, 0, 0, 0, 0, 0, 0}},            // 95
+	{"ShortGreenTorch", 56, [NumLabels]int{942, 0, 0, 0, 0, 0, 0, 0}},           // 96
+	{"ShortRedTorch", 57, [NumLabels]int{946, 0, 0, 0, 0, 0, 0, 0}},             // 97
+	{"Stalagtite", 47, [NumLabels]int{906, 0, 0, 0, 0, 0, 0, 0}},                // 98
+	{"TechPillar", 48, [NumLabels]int{916, 0, 0, 0, 0, 0, 0, 0}},                // 99
+	{"Candlestick", 34, [NumLabels]int{911, 0, 0, 0, 0, 0, 0, 0}},               // 100
+	{"Candelabra", 35, [NumLabels]int{912, 0, 0, 0, 0, 0, 0, 0}},                // 101
+	{"BloodyTwitch", 49, [NumLabels]int{888, 0, 0, 0, 0, 0, 0, 0}},              // 102
+	{"Meat2", 50, [NumLabels]int{902, 0, 0, 0, 0, 0, 0, 0}},                     // 103
+	{"Meat3", 51, [NumLabels]int{903, 0, 0, 0, 0, 0, 0, 0}},                     // 104
+	{"Meat4", 52, [NumLabels]int{904, 0, 0, 0, 0, 0, 0, 0}},                     // 105
+	{"Meat5", 53, [NumLabels]int{905, 0, 0, 0, 0, 0, 0, 0}},                     // 106
+	{"NonsolidMeat2", 59, [NumLabels]int{902, 0, 0, 0, 0, 0, 0, 0}},             // 107
+	{"NonsolidMeat4", 60, [NumLabels]int{904, 0, 0, 0, 0, 0, 0, 0}},             // 108
+	{"NonsolidMeat3", 61, [NumLabels]int{903, 0, 0, 0, 0, 0, 0, 0}},             // 109
+	{"NonsolidMeat5", 62, [NumLabels]int{905, 0, 0, 0, 0, 0, 0, 0}},             // 110
+	{"NonsolidTwitch", 63, [NumLabels]int{888, 0, 0, 0, 0, 0, 0, 0}},            // 111
+	{"DeadCacodemon", 22, [NumLabels]int{515, 0, 0, 0, 0, 0, 0, 0}},             // 112
+	{"DeadMarine", 15, [NumLabels]int{164, 0, 0, 0, 0, 0, 0, 0}},                // 113
+	{"DeadZombieMan", 18, [NumLabels]int{193, 0, 0, 0, 0, 0, 0, 0}},             // 114
+	{"DeadDemon", 21, [NumLabels]int{495, 0, 0, 0, 0, 0, 0, 0}},                 // 115
+	{"DeadLostSoul", 23, [NumLabels]int{600, 0, 0, 0, 0, 0, 0, 0}},              // 116
+	{"DeadDoomImp", 20, [NumLabels]int{461, 0, 0, 0, 0, 0, 0, 0}},               // 117
+	{"DeadShotgunGuy", 19, [NumLabels]int{226, 0, 0, 0, 0, 0, 0, 0}},            // 118
+	{"GibbedMarine", 10, [NumLabels]int{173, 0, 0, 0, 0, 0, 0, 0}},              // 119
+	{"GibbedMarineExtra", 12, [NumLabels]int{173, 0, 0, 0, 0, 0, 0, 0}},         // 120
+	{"HeadsOnAStick", 28, [NumLabels]int{894, 0, 0, 0, 0, 0, 0, 0}},             // 121
+	{"Gibs", 24, [NumLabels]int{895, 0, 0, 0, 0, 0, 0, 0}},                      // 122
+	{"HeadOnAStick", 27, [NumLabels]int{896, 0, 0, 0, 0, 0, 0, 0}},              // 123
+	{"HeadCandles", 29, [NumLabels]int{897, 0, 0, 0, 0, 0, 0, 0}},               // 124
+	{"DeadStick", 25, [NumLabels]int{899, 0, 0, 0, 0, 0, 0, 0}},                 // 125
+	{"LiveStick", 26, [NumLabels]int{900, 0, 0, 0, 0, 0, 0, 0}},                 // 126
+	{"BigTree", 54, [NumLabels]int{915, 0, 0, 0, 0, 0, 0, 0}},                   // 127
+	{"BurningBarrel", 70, [NumLabels]int{813, 0, 0, 0, 0, 0, 0, 0}},             // 128
+	{"HangNoGuts", 73, [NumLabels]int{950, 0, 0, 0, 0, 0, 0, 0}},                // 129
+	{"HangBNoBrain", 74, [NumLabels]int{951, 0, 0, 0, 0, 0, 0, 0}},              // 130
+	{"HangTLookingDown", 75, [NumLabels]int{952, 0, 0, 0, 0, 0, 0, 0}},          // 131
+	{"HangTSkull", 76, [NumLabels]int{953, 0, 0, 0, 0, 0, 0, 0}},                // 132
+	{"HangTLookingUp", 77, [NumLabels]int{954, 0, 0, 0, 0, 0, 0, 0}},            // 133
+	{"HangTNoBrain", 78, [NumLabels]int{955, 0, 0, 0, 0, 0, 0, 0}},              // 134
+	{"ColonGibs", 79, [NumLabels]int{956, 0, 0, 0, 0, 0, 0, 0}},                 // 135
+	{"SmallBloodPool", 80, [NumLabels]int{957, 0, 0, 0, 0, 0, 0, 0}},            // 136
+	{"BrainStem", 81, [NumLabels]int{958, 0, 0, 0, 0, 0, 0, 0}},                 // 137
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// spriteImage is one sprite rotation with its offsets: leftoffs and topoffs
+// are the position of the sprite's origin, on the floor at its center,
+// measured from the top left of the image.
+type spriteImage struct {
+	Name     string
+	Img      *image.NRGBA
+	Leftoffs int
+	Topoffs  int
+}
+
+// decodeSpriteLump reads a sprite lump in the Doom patch format or as a PNG
+// with its offsets in a grAb chunk.
+func decodeSpriteLump(data []byte, pal color.Palette) (img *image.NRGBA, leftoffs, topoffs int, err error) {
+	if bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		var decoded image.Image
+		if decoded, err = png.Decode(bytes.NewReader(data)); err != nil {
+			return
+		}
+		img = image.NewNRGBA(decoded.Bounds().Sub(decoded.Bounds().Min))
+		draw.Draw(img, img.Rect, decoded, decoded.Bounds().Min, draw.Src)
+		leftoffs, topoffs = readGrAb(data)
+		return
+	}
+
+	paletted, mask, leftoffs, topoffs, err := decodePatch(data, pal)
+	if err != nil {
+		return
+	}
+	img = image.NewNRGBA(paletted.Rect)
+	draw.DrawMask(img, img.Rect, paletted, image.Point{}, mask, image.Point{}, draw.Src)
+	return
+}
+
+// readGrAb returns the offsets of a PNG's grAb chunk, 0, 0 if it has none.
+func readGrAb(data []byte) (leftoffs, topoffs int) {
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		if kind == "grAb" && length >= 8 && pos+16 <= len(data) {
+			return int(int32(binary.BigEndian.Uint32(data[pos+8:]))), int(int32(binary.BigEndian.Uint32(data[pos+12:])))
+		}
+		if kind == "IDAT" || kind == "IEND" {
+			break
+		}
+		// length, type, data, CRC:
+		pos += 12 + length
+	}
+	return 0, 0
+}
+
+// encodePNGGrAb writes img as a PNG with the offsets in a grAb chunk right
+// after the header, where ZDoom-derived ports look for them.
+func encodePNGGrAb(w io.Writer, img image.Image, leftoffs, topoffs int) error {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	// signature and IHDR chunk:
+	headerEnd := 8 + 12 + int(binary.BigEndian.Uint32(b[8:12]))
+
+	chunk := make([]byte, 20)
+	binary.BigEndian.PutUint32(chunk[0:4], 8)
+	copy(chunk[4:8], "grAb")
+	binary.BigEndian.PutUint32(chunk[8:12], uint32(int32(leftoffs)))
+	binary.BigEndian.PutUint32(chunk[12:16], uint32(int32(topoffs)))
+	binary.BigEndian.PutUint32(chunk[16:20], crc32.ChecksumIEEE(chunk[4:16]))
+
+	file := &errWriter{w: w}
+	_, _ = file.Write(b[:headerEnd])
+	_, _ = file.Write(chunk)
+	_, _ = file.Write(b[headerEnd:])
+	return file.err
+}
+
+func savePNGGrAb(path string, img image.Image, leftoffs, topoffs int) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return encodePNGGrAb(w, img, leftoffs, topoffs)
+	})
+}
+
+// flipSprite mirrors a sprite the way the engine draws the second frame of a
+// lump like TROOA2A8: the columns are reversed while the offsets stay.
+func flipSprite(s spriteImage, name string) spriteImage {
+	b := s.Img.Rect
+	flipped := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			flipped.SetNRGBA(b.Max.X-1-(x-b.Min.X), y, s.Img.NRGBAAt(x, y))
+		}
+	}
+	return spriteImage{Name: name, Img: flipped, Leftoffs: s.Leftoffs, Topoffs: s.Topoffs}
+}
+
+// contactSheet places the rotations of a frame side by side, gap pixels
+// apart, with all origins on one baseline and at the same spot in each cell.
+func contactSheet(rotations []spriteImage, gap int) *image.NRGBA {
+	var left, right, top, bottom int
+	for _, r := range rotations {
+		w, h := r.Img.Rect.Dx(), r.Img.Rect.Dy()
+		left = imax(left, r.Leftoffs)
+		right = imax(right, w-r.Leftoffs)
+		top = imax(top, r.Topoffs)
+		bottom = imax(bottom, h-r.Topoffs)
+	}
+	cellW, cellH := left+right, top+bottom
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, len(rotations)*(cellW+gap)-gap, cellH))
+	for i, r := range rotations {
+		at := image.Pt(i*(cellW+gap)+left-r.Leftoffs, top-r.Topoffs)
+		draw.Draw(sheet, r.Img.Rect.Add(at), r.Img, r.Img.Rect.Min, draw.Over)
+	}
+	return sheet
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// runExtract implements the extract command: it writes every rotation of
+// the winning sprite lumps as a PNG keeping its offsets in a grAb chunk,
+// and a contact sheet of each frame's rotations.
+func runExtract(wc *WADCollection, pal color.Palette, args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ContinueOnError)
+	out := fs.String("out", "sprites", "directory to write one subdirectory of PNGs per sprite into")
+	only := fs.String("sprites", "", "comma-separated sprite names to extract, e.g. TROO,SARG; all if empty")
+	sheets := fs.Bool("sheets", true, "also write a contact sheet per frame with its rotations aligned on the origin")
+	gap := fs.Int("gap", 4, "pixels between the rotations of a contact sheet")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("extract: unexpected argument %q", fs.Arg(0))
+	}
+	wanted := map[string]bool{}
+	for _, name := range strings.Split(*only, ",") {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			wanted[name] = true
+		}
+	}
+
+	// as in the engine, the last lump of a rotation within a WAD wins, and
+	// WADs loaded later override earlier ones:
+	frames := map[string]map[byte]spriteImage{}
+	for _, wad := range wc.Ordered {
+		found := map[string]map[byte]spriteImage{}
+		var decodeErr error
+		wad.IterateSprites(func(lump *Lump) bool {
+			s := lump.Name
+			if len(s) < 6 || (len(wanted) > 0 && !wanted[s[:4]]) || len(lump.Data) == 0 {
+				return false
+			}
+			img, leftoffs, topoffs, err := decodeSpriteLump(lump.Data, pal)
+			if err != nil {
+				decodeErr = fmt.Errorf("%s: %s: %w", wad.Name, s, err)
+				return true
+			}
+			add := func(frame, rotation byte, si spriteImage) {
+				key := s[:4] + string(frame)
+				if found[key] == nil {
+					found[key] = map[byte]spriteImage{}
+				}
+				found[key][rotation] = si
+			}
+			si := spriteImage{Name: s[:6], Img: img, Leftoffs: leftoffs, Topoffs: topoffs}
+			add(s[4], s[5], si)
+			if len(s) == 8 {
+				add(s[6], s[7], flipSprite(si, s[:4]+s[6:8]))
+			}
+			return false
+		})
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		for key, rotations := range found {
+			if frames[key] == nil {
+				frames[key] = map[byte]spriteImage{}
+			}
+			for rotation, si := range rotations {
+				if _, ok := frames[key][rotation]; !ok {
+					frames[key][rotation] = si
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(frames))
+	for key := range frames {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// single-rotation frames, then all 16 angles in order:
+	rotationOrder, _ := spriteRotationChars(16)
+	rotationOrder = "0" + rotationOrder
+	for _, key := range keys {
+		dir := filepath.Join(*out, key[:4])
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		var rotations []spriteImage
+		for i := 0; i < len(rotationOrder); i++ {
+			r, ok := frames[key][rotationOrder[i]]
+			if !ok {
+				continue
+			}
+			rotations = append(rotations, r)
+			path := filepath.Join(dir, r.Name+".png")
+			if err := savePNGGrAb(path, r.Img, r.Leftoffs, r.Topoffs); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("%s: %d rotations\n", key, len(rotations))
+
+		if *sheets && len(rotations) > 0 {
+			path := filepath.Join(dir, "sheet-"+key+".png")
+			if err := savePNG(path, contactSheet(rotations, *gap)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
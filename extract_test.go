@@ -0,0 +1,66 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPatchLump returns a sprite lump of a solid patch width pixels wide.
+func testPatchLump(name string, width int) Lump {
+	img := image.NewPaletted(image.Rect(0, 0, width, 4), testPalette())
+	mask := image.NewAlpha(img.Rect)
+	for i := range mask.Pix {
+		mask.Pix[i] = 0xFF
+	}
+	return Lump{Name: name, Data: encodePatch(img, mask, width/2, 4)}
+}
+
+// testLumpWAD returns a WAD holding lumps.
+func testLumpWAD(name string, lumps ...Lump) *WAD {
+	wad := &WAD{Name: name, Lumps: lumps, LumpByName: map[string]uint32{}}
+	for i, l := range lumps {
+		wad.LumpByName[l.Name] = uint32(i)
+	}
+	return wad
+}
+
+func TestExtractOverrides(t *testing.T) {
+	iwad := testLumpWAD("iwad.wad",
+		Lump{Name: "S_START"},
+		testPatchLump("TROOA1", 1),
+		testPatchLump("TROOB1", 5),
+		// the engine uses the last of a name within a WAD:
+		testPatchLump("TROOA1", 2),
+		testPatchLump("TROOC2C8", 6),
+		Lump{Name: "S_END"},
+	)
+	pwad := testLumpWAD("pwad.wad",
+		Lump{Name: "SS_START"},
+		testPatchLump("TROOB1", 3),
+		Lump{Name: "S_END"},
+	)
+	wc := &WADCollection{Ordered: []*WAD{pwad, iwad}}
+
+	dir := t.TempDir()
+	if err := runExtract(wc, testPalette(), []string{"-out", dir, "-sheets=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, width := range map[string]int{"TROOA1": 2, "TROOB1": 3, "TROOC2": 6, "TROOC8": 6} {
+		b, err := os.ReadFile(filepath.Join(dir, "TROO", name+".png"))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		img, leftoffs, topoffs, err := decodeSpriteLump(b, nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if img.Rect.Dx() != width || leftoffs != width/2 || topoffs != 4 {
+			t.Errorf("%s: %d pixels wide at %d, %d; want %d at %d, 4", name, img.Rect.Dx(), leftoffs, topoffs, width, width/2)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"awesomeProject/palette"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Game describes what differs between the id Tech 1 games whose sprites can
+// be voxelized.
+type Game struct {
+	Name string
+	// Signature lists lumps an IWAD of the game contains.
+	Signature []string
+	// IWADs are the file names the IWAD goes by, in order of preference.
+	IWADs []string
+	// PWADs are loaded from next to the IWAD when present and no -file is
+	// given, e.g. sprite fixes.
+	PWADs []string
+
+	// Sprites are voxelized when no -actor is given.
+	Sprites []string
+	// DoomActors tells whether the built-in Doom actor tables apply.
+	DoomActors bool
+	// OffsetFixes tells whether the hand-tuned sprite offsets for the
+	// original Doom art apply.
+	OffsetFixes bool
+
+	// PlayerRange is the range of palette indices that player translations
+	// recolor.
+	PlayerRange string
+	// Translations are the player translations, either ramps starting at a
+	// palette index or translation table lumps.
+	Translations []playerTranslation
+}
+
+// playerTranslation recolors the player range onto the 16 color ramp at
+// Base, or by the translation table in lump Lump.
+type playerTranslation struct {
+	Name string
+	Base uint8
+	Lump string
+}
+
+// doomSprites are the Doom II monsters voxelized by default.
+var doomSprites = []string{"CYBR", "VILE", "POSS", "SPOS", "CPOS", "TROO", "SARG"}
+
+// doomTranslations are Doom's gray, brown and red player colors.
+var doomTranslations = []playerTranslation{
+	{Name: "gray", Base: 0x60},
+	{Name: "brown", Base: 0x40},
+	{Name: "red", Base: 0x20},
+}
+
+// hereticTranslations are Heretic's yellow, red and blue player colors.
+var hereticTranslations = []playerTranslation{
+	{Name: "yellow", Base: 114},
+	{Name: "red", Base: 145},
+	{Name: "blue", Base: 190},
+}
+
+// games are checked in order, so that games whose signature includes another
+// one's come first.
+var games = []*Game{
+	// freedm.wad holds the FREEDOOM lump as well:
+	{
+		Name:         "FreeDM",
+		Signature:    []string{"FREEDM"},
+		IWADs:        []string{"freedm.wad"},
+		Sprites:      doomSprites,
+		DoomActors:   true,
+		PlayerRange:  palette.PlayerRange,
+		Translations: doomTranslations,
+	},
+	{
+		Name:         "Freedoom: Phase 2",
+		Signature:    []string{"FREEDOOM", "MAP01"},
+		IWADs:        []string{"freedoom2.wad"},
+		Sprites:      doomSprites,
+		DoomActors:   true,
+		PlayerRange:  palette.PlayerRange,
+		Translations: doomTranslations,
+	},
+	{
+		Name:         "Freedoom: Phase 1",
+		Signature:    []string{"FREEDOOM", "E1M1"},
+		IWADs:        []string{"freedoom1.wad"},
+		Sprites:      []string{"POSS", "SPOS", "TROO", "SARG"},
+		DoomActors:   true,
+		PlayerRange:  palette.PlayerRange,
+		Translations: doomTranslations,
+	},
+	{
+		Name:        "Hexen",
+		Signature:   []string{"TITLE", "MAP01", "MAP40", "WINNOWR"},
+		IWADs:       []string{"hexen.wad"},
+		Sprites:     []string{"ETTN", "CENT", "DEMN", "BISH", "WRTH", "FDMN"},
+		PlayerRange: "146-163",
+		// the tables of the fighter's colors; the other classes have
+		// their own sets:
+		Translations: []playerTranslation{
+			{Name: "trantbl0", Lump: "TRANTBL0"},
+			{Name: "trantbl1", Lump: "TRANTBL1"},
+			{Name: "trantbl2", Lump: "TRANTBL2"},
+		},
+	},
+	{
+		Name:      "Strife",
+		Signature: []string{"MAP01", "ENDSTRF"},
+		IWADs:     []string{"strife1.wad"},
+		Sprites:   []string{"AGRD", "ROB1", "ROB2", "STLK"},
+		// Strife builds its player translations in code, from more than
+		// one range; they are not reproduced here.
+	},
+	{
+		Name:         "Heretic",
+		Signature:    []string{"E1M1", "E2M1", "TITLE", "MUS_E1M1"},
+		IWADs:        []string{"heretic.wad"},
+		Sprites:      []string{"IMPX", "MUMM", "KNIG", "BEAS", "CLNK", "WZRD", "SNKE", "MNTR"},
+		PlayerRange:  "225-240",
+		Translations: hereticTranslations,
+	},
+	{
+		// the shareware episode has no E2M1 and only the monsters of
+		// episode one:
+		Name:         "Heretic Shareware",
+		Signature:    []string{"E1M1", "TITLE", "MUS_E1M1"},
+		IWADs:        []string{"heretic1.wad"},
+		Sprites:      []string{"IMPX", "MUMM", "KNIG", "WZRD", "HEAD"},
+		PlayerRange:  "225-240",
+		Translations: hereticTranslations,
+	},
+	{
+		Name:         "Doom II",
+		Signature:    []string{"MAP01"},
+		IWADs:        []string{"doom2.wad", "plutonia.wad", "tnt.wad"},
+		PWADs:        []string{"D2SPFX20.WAD"},
+		Sprites:      doomSprites,
+		DoomActors:   true,
+		OffsetFixes:  true,
+		PlayerRange:  palette.PlayerRange,
+		Translations: doomTranslations,
+	},
+	{
+		Name:         "Doom",
+		Signature:    []string{"E1M1"},
+		IWADs:        []string{"doom.wad", "doom1.wad"},
+		Sprites:      []string{"CYBR", "POSS", "SPOS", "TROO", "SARG"},
+		DoomActors:   true,
+		OffsetFixes:  true,
+		PlayerRange:  palette.PlayerRange,
+		Translations: doomTranslations,
+	},
+}
+
+// identifyGame returns the game whose signature lumps the WAD contains.
+func identifyGame(wad *WAD) (*Game, error) {
+	for _, g := range games {
+		found := true
+		for _, name := range g.Signature {
+			if _, ok := wad.LumpByName[name]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not the IWAD of a known game", wad.Name)
+}
+
+// findIWAD returns the path of the first IWAD of a known game in dir, with
+// file names compared without regard to case.
+func findIWAD(dir string) (string, error) {
+	var names []string
+	for _, g := range gamesByPreference() {
+		for _, name := range g.IWADs {
+			if path := findFile(dir, name); path != "" {
+				return path, nil
+			}
+			names = append(names, name)
+		}
+	}
+	return "", fmt.Errorf("no IWAD in %q; looked for %s", dir, strings.Join(names, ", "))
+}
+
+// gamesByPreference lists Doom II first, then the other games in order.
+func gamesByPreference() []*Game {
+	ordered := append([]*Game(nil), games...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Name == "Doom II" && ordered[j].Name != "Doom II"
+	})
+	return ordered
+}
+
+// findFile returns the path of the file name in dir, compared without regard
+// to case, or "" if there is none.
+func findFile(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if strings.EqualFold(e.Name(), name) {
+			return filepath.Join(dir, e.Name())
+		}
+	}
+	return ""
+}
+
+// loadTranslations reads the translation tables the game keeps in lumps,
+// keyed by lump name. Ramps are built per model from its translatable range.
+func (g *Game) loadTranslations(wc *WADCollection) (tables map[string]palette.Translation, err error) {
+	tables = map[string]palette.Translation{}
+	for _, t := range g.Translations {
+		if t.Lump == "" {
+			continue
+		}
+		lump := wc.FindLumpBetween("", "", func(s string) bool {
+			return s == t.Lump
+		})
+		if lump == nil {
+			return nil, fmt.Errorf("could not find %s", t.Lump)
+		}
+		if tables[t.Lump], err = palette.ParseTranslation(lump.Data); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Lump, err)
+		}
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+// testWAD returns a WAD holding empty lumps of the given names.
+func testWAD(name string, lumps ...string) *WAD {
+	wad := &WAD{Name: name, LumpByName: map[string]uint32{}}
+	for i, s := range lumps {
+		wad.Lumps = append(wad.Lumps, Lump{Name: s})
+		wad.LumpByName[s] = uint32(i)
+	}
+	return wad
+}
+
+func TestIdentifyGame(t *testing.T) {
+	tests := []struct {
+		lumps []string
+		want  string
+	}{
+		{[]string{"PLAYPAL", "FREEDOOM", "MAP01"}, "Freedoom: Phase 2"},
+		{[]string{"PLAYPAL", "FREEDOOM", "E1M1"}, "Freedoom: Phase 1"},
+		{[]string{"PLAYPAL", "FREEDOOM", "FREEDM", "MAP01"}, "FreeDM"},
+		{[]string{"PLAYPAL", "TITLE", "MAP01", "MAP40", "WINNOWR"}, "Hexen"},
+		{[]string{"PLAYPAL", "MAP01", "ENDSTRF"}, "Strife"},
+		{[]string{"PLAYPAL", "TITLE", "E1M1", "E2M1", "MUS_E1M1"}, "Heretic"},
+		{[]string{"PLAYPAL", "TITLE", "E1M1", "MUS_E1M1"}, "Heretic Shareware"},
+		{[]string{"PLAYPAL", "MAP01"}, "Doom II"},
+		{[]string{"PLAYPAL", "TITLEPIC", "MAP01", "MAP40"}, "Doom II"},
+		{[]string{"PLAYPAL", "E1M1", "E2M1"}, "Doom"},
+		{[]string{"PLAYPAL", "E1M1"}, "Doom"},
+	}
+	for _, tt := range tests {
+		g, err := identifyGame(testWAD("test.wad", tt.lumps...))
+		if err != nil {
+			t.Errorf("%v: %v", tt.lumps, err)
+			continue
+		}
+		if g.Name != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.lumps, g.Name, tt.want)
+		}
+	}
+
+	if g, err := identifyGame(testWAD("test.wad", "PLAYPAL", "TITLE")); err == nil {
+		t.Errorf("unknown IWAD identified as %s", g.Name)
+	}
+}
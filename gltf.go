@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// GLTFOptions controls how models are written as glTF.
+type GLTFOptions struct {
+	// Frames is how multiple frames are stored: "nodes" gives every frame
+	// its own mesh and node, "morph" stores them as morph targets of one mesh.
+	Frames string
+	// FrameTime is the duration of a frame in the animation, in seconds.
+	FrameTime float64
+}
+
+func DefaultGLTFOptions() GLTFOptions {
+	return GLTFOptions{
+		Frames:    "nodes",
+		FrameTime: 0.25,
+	}
+}
+
+// meshFrame is the greedy mesh of one animation frame.
+type meshFrame struct {
+	Name  string
+	Quads []Quad
+	// Origin is the voxel coordinate placed at the scene origin:
+	Origin [3]int
+}
+
+// glTF component and target constants:
+const (
+	gltfFloat         = 5126
+	gltfUnsignedInt   = 5125
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+	gltfNearest       = 9728
+	gltfClampToEdge   = 33071
+)
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int  `json:"buffer"`
+	ByteOffset int  `json:"byteOffset"`
+	ByteLength int  `json:"byteLength"`
+	Target     *int `json:"target,omitempty"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    int              `json:"indices"`
+	Material   int              `json:"material"`
+	Targets    []map[string]int `json:"targets,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+	Weights    []float32       `json:"weights,omitempty"`
+}
+
+type gltfNode struct {
+	Name     string `json:"name,omitempty"`
+	Mesh     *int   `json:"mesh,omitempty"`
+	Children []int  `json:"children,omitempty"`
+}
+
+type gltfChannelTarget struct {
+	Node int    `json:"node"`
+	Path string `json:"path"`
+}
+
+type gltfChannel struct {
+	Sampler int               `json:"sampler"`
+	Target  gltfChannelTarget `json:"target"`
+}
+
+type gltfAnimationSampler struct {
+	Input         int    `json:"input"`
+	Interpolation string `json:"interpolation"`
+	Output        int    `json:"output"`
+}
+
+type gltfAnimation struct {
+	Name     string                 `json:"name,omitempty"`
+	Channels []gltfChannel          `json:"channels"`
+	Samplers []gltfAnimationSampler `json:"samplers"`
+}
+
+type gltfDocument struct {
+	Asset struct {
+		Version   string `json:"version"`
+		Generator string `json:"generator"`
+	} `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []map[string]any `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []map[string]any `json:"materials"`
+	Textures    []map[string]any `json:"textures"`
+	Samplers    []map[string]any `json:"samplers"`
+	Images      []map[string]any `json:"images"`
+	Animations  []gltfAnimation  `json:"animations,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []map[string]any `json:"buffers"`
+}
+
+// gltfBuilder accumulates the binary buffer and the objects describing it.
+type gltfBuilder struct {
+	doc gltfDocument
+	bin bytes.Buffer
+}
+
+// view appends data to the binary buffer, 4-byte aligned, and returns its
+// buffer view; target is 0 for data that is not vertex or index data.
+func (g *gltfBuilder) view(data []byte, target int) int {
+	for g.bin.Len()%4 != 0 {
+		g.bin.WriteByte(0)
+	}
+	v := gltfBufferView{ByteOffset: g.bin.Len(), ByteLength: len(data)}
+	if target != 0 {
+		v.Target = &target
+	}
+	g.bin.Write(data)
+	g.doc.BufferViews = append(g.doc.BufferViews, v)
+	return len(g.doc.BufferViews) - 1
+}
+
+// floats adds an accessor over float vectors of n components each.
+func (g *gltfBuilder) floats(values []float32, n int, typ string, target int, bounds bool) int {
+	data := &bytes.Buffer{}
+	_ = binary.Write(data, binary.LittleEndian, values)
+	a := gltfAccessor{
+		BufferView:    g.view(data.Bytes(), target),
+		ComponentType: gltfFloat,
+		Count:         len(values) / n,
+		Type:          typ,
+	}
+	if bounds {
+		a.Min = make([]float32, n)
+		a.Max = make([]float32, n)
+		for c := 0; c < n; c++ {
+			a.Min[c] = float32(math.Inf(1))
+			a.Max[c] = float32(math.Inf(-1))
+		}
+		for i, v := range values {
+			if v < a.Min[i%n] {
+				a.Min[i%n] = v
+			}
+			if v > a.Max[i%n] {
+				a.Max[i%n] = v
+			}
+		}
+		if a.Count == 0 {
+			a.Min = make([]float32, n)
+			a.Max = make([]float32, n)
+		}
+	}
+	g.doc.Accessors = append(g.doc.Accessors, a)
+	return len(g.doc.Accessors) - 1
+}
+
+// primitives adds the index data of the quads, six indices per quad in idx,
+// and returns one primitive for each material of meshMaterials that has
+// faces; the primitives share the vertex attributes and morph targets.
+func (g *gltfBuilder) primitives(quads []Quad, idx []uint32, attrs map[string]int, targets []map[string]int) (prims []gltfPrimitive) {
+	groups := make([][]uint32, materialCount(quads))
+	for k, q := range quads {
+		groups[q.material()] = append(groups[q.material()], idx[k*6:k*6+6]...)
+	}
+	for mat, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		prims = append(prims, gltfPrimitive{
+			Attributes: attrs,
+			Indices:    g.indices(group),
+			Material:   mat,
+			Targets:    targets,
+		})
+	}
+	return
+}
+
+func (g *gltfBuilder) indices(values []uint32) int {
+	data := &bytes.Buffer{}
+	_ = binary.Write(data, binary.LittleEndian, values)
+	g.doc.Accessors = append(g.doc.Accessors, gltfAccessor{
+		BufferView:    g.view(data.Bytes(), gltfElementBuffer),
+		ComponentType: gltfUnsignedInt,
+		Count:         len(values),
+		Type:          "SCALAR",
+	})
+	return len(g.doc.Accessors) - 1
+}
+
+// meshVertices returns the positions, normals, texture coordinates and
+// indices of the quads; every quad has its own four vertices so it can carry
+// its own normal and color.
+func meshVertices(quads []Quad, origin [3]int, rows int) (pos, norm, uv []float32, idx []uint32) {
+	for _, q := range quads {
+		base := uint32(len(pos) / 3)
+		n := meshVertex(q.Normal, [3]int{})
+		u, v := paletteUV(q.Color, q.Emissive, rows)
+		for _, p := range q.Corners {
+			m := meshVertex(p, origin)
+			pos = append(pos, float32(m[0]), float32(m[1]), float32(m[2]))
+			norm = append(norm, float32(n[0]), float32(n[1]), float32(n[2]))
+			// glTF texture coordinates start at the top of the image:
+			uv = append(uv, float32(u), float32(1-v))
+		}
+		idx = append(idx, base, base+1, base+2, base, base+2, base+3)
+	}
+	return
+}
+
+func saveGLB(path string, frames []meshFrame, pal color.Palette, opts GLTFOptions) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		return writeGLB(w, frames, pal, opts)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeGLB writes the frames as a binary glTF 2.0 file textured with the
+// palette. Coordinates follow meshVertex: one unit per voxel, Y up, and the
+// same origin as the VOX export. More than one frame adds an animation that
+// steps through them.
+func writeGLB(w io.Writer, frames []meshFrame, pal color.Palette, opts GLTFOptions) (err error) {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to save")
+	}
+	if opts.Frames != "nodes" && opts.Frames != "morph" {
+		return fmt.Errorf("glTF frames must be nodes or morph, got %q", opts.Frames)
+	}
+	faces := 0
+	quads := make([][]Quad, len(frames))
+	for f, fr := range frames {
+		faces += len(fr.Quads)
+		quads[f] = fr.Quads
+	}
+	rows := paletteRows(quads...)
+	if faces == 0 {
+		return fmt.Errorf("model is empty")
+	}
+
+	g := &gltfBuilder{}
+	doc := &g.doc
+	doc.Asset.Version = "2.0"
+	doc.Asset.Generator = "doom-voxelizer"
+
+	// palette texture, sampled without filtering:
+	tex := &bytes.Buffer{}
+	if err = png.Encode(tex, paletteTexture(pal, rows)); err != nil {
+		return
+	}
+	doc.Images = []map[string]any{{"bufferView": g.view(tex.Bytes(), 0), "mimeType": "image/png"}}
+	doc.Samplers = []map[string]any{{
+		"magFilter": gltfNearest, "minFilter": gltfNearest,
+		"wrapS": gltfClampToEdge, "wrapT": gltfClampToEdge,
+	}}
+	doc.Textures = []map[string]any{{"sampler": 0, "source": 0}}
+	doc.Materials = []map[string]any{{
+		"name": "palette",
+		"pbrMetallicRoughness": map[string]any{
+			"baseColorTexture": map[string]any{"index": 0},
+			"metallicFactor":   0,
+			"roughnessFactor":  1,
+		},
+	}}
+
+	// glowing faces light themselves through a second texture:
+	if rows > 16 {
+		emit := &bytes.Buffer{}
+		if err = png.Encode(emit, emissiveTexture(pal)); err != nil {
+			return
+		}
+		doc.Images = append(doc.Images, map[string]any{"bufferView": g.view(emit.Bytes(), 0), "mimeType": "image/png"})
+		doc.Textures = append(doc.Textures, map[string]any{"sampler": 0, "source": 1})
+		doc.Materials[0]["emissiveTexture"] = map[string]any{"index": 1}
+		doc.Materials[0]["emissiveFactor"] = []float32{1, 1, 1}
+	}
+
+	// the translation range gets a copy of the material to recolor:
+	for _, name := range meshMaterials[1:materialCount(quads...)] {
+		mat := map[string]any{}
+		for k, v := range doc.Materials[0] {
+			mat[k] = v
+		}
+		mat["name"] = name
+		doc.Materials = append(doc.Materials, mat)
+	}
+
+	// root node holding the frames:
+	doc.Nodes = []gltfNode{{Name: frames[0].Name}}
+	doc.Scenes = []map[string]any{{"nodes": []int{0}}}
+
+	// keyframe times, shared by all animation channels:
+	times := make([]float32, len(frames))
+	for f := range frames {
+		times[f] = float32(float64(f) * opts.FrameTime)
+	}
+	anim := gltfAnimation{Name: "frames"}
+
+	switch {
+	case len(frames) == 1 || opts.Frames == "nodes":
+		var input int
+		if len(frames) > 1 {
+			input = g.floats(times, 1, "SCALAR", 0, true)
+		}
+		for f, fr := range frames {
+			doc.Nodes[0].Children = append(doc.Nodes[0].Children, len(doc.Nodes))
+			doc.Nodes = append(doc.Nodes, gltfNode{Name: fr.Name})
+			node := &doc.Nodes[len(doc.Nodes)-1]
+
+			// buffers cannot be empty, so empty frames get no mesh:
+			if len(fr.Quads) > 0 {
+				mesh := len(doc.Meshes)
+				node.Mesh = &mesh
+				pos, norm, uv, idx := meshVertices(fr.Quads, fr.Origin, rows)
+				attrs := map[string]int{
+					"POSITION":   g.floats(pos, 3, "VEC3", gltfArrayBuffer, true),
+					"NORMAL":     g.floats(norm, 3, "VEC3", gltfArrayBuffer, false),
+					"TEXCOORD_0": g.floats(uv, 2, "VEC2", gltfArrayBuffer, false),
+				}
+				doc.Meshes = append(doc.Meshes, gltfMesh{
+					Name:       fr.Name,
+					Primitives: g.primitives(fr.Quads, idx, attrs, nil),
+				})
+			}
+
+			if len(frames) > 1 {
+				// show the node only during its own frame:
+				scale := make([]float32, 0, len(frames)*3)
+				for k := range frames {
+					s := float32(0)
+					if k == f {
+						s = 1
+					}
+					scale = append(scale, s, s, s)
+				}
+				anim.Samplers = append(anim.Samplers, gltfAnimationSampler{
+					Input:         input,
+					Interpolation: "STEP",
+					Output:        g.floats(scale, 3, "VEC3", 0, false),
+				})
+				anim.Channels = append(anim.Channels, gltfChannel{
+					Sampler: len(anim.Samplers) - 1,
+					Target:  gltfChannelTarget{Node: len(doc.Nodes) - 1, Path: "scale"},
+				})
+			}
+		}
+
+	default:
+		// morph targets need the same vertices in every frame, so the mesh
+		// holds the faces of all frames. Faces not in the base frame are
+		// collapsed onto their centers, and target k moves frame k's faces
+		// out and the base frame's faces in.
+		var pos, norm, uv []float32
+		var idx []uint32
+		var all []Quad
+		var actual, collapsed [][]float32
+		for _, fr := range frames {
+			all = append(all, fr.Quads...)
+			p, n, t, i := meshVertices(fr.Quads, fr.Origin, rows)
+			base := uint32(len(pos) / 3)
+			for k := range i {
+				i[k] += base
+			}
+
+			c := make([]float32, len(p))
+			for q := 0; q < len(p); q += 12 {
+				for a := 0; a < 3; a++ {
+					mid := (p[q+a] + p[q+3+a] + p[q+6+a] + p[q+9+a]) / 4
+					for v := 0; v < 4; v++ {
+						c[q+v*3+a] = mid
+					}
+				}
+			}
+
+			if len(actual) == 0 {
+				pos = append(pos, p...)
+			} else {
+				pos = append(pos, c...)
+			}
+			norm = append(norm, n...)
+			uv = append(uv, t...)
+			idx = append(idx, i...)
+			actual = append(actual, p)
+			collapsed = append(collapsed, c)
+		}
+
+		attrs := map[string]int{
+			"POSITION":   g.floats(pos, 3, "VEC3", gltfArrayBuffer, true),
+			"NORMAL":     g.floats(norm, 3, "VEC3", gltfArrayBuffer, false),
+			"TEXCOORD_0": g.floats(uv, 2, "VEC2", gltfArrayBuffer, false),
+		}
+		var targets []map[string]int
+		for k := 1; k < len(frames); k++ {
+			delta := make([]float32, 0, len(pos))
+			for f := range frames {
+				for i := range actual[f] {
+					switch f {
+					case 0:
+						delta = append(delta, collapsed[f][i]-actual[f][i])
+					case k:
+						delta = append(delta, actual[f][i]-collapsed[f][i])
+					default:
+						delta = append(delta, 0)
+					}
+				}
+			}
+			targets = append(targets, map[string]int{
+				"POSITION": g.floats(delta, 3, "VEC3", gltfArrayBuffer, true),
+			})
+		}
+
+		mesh := 0
+		doc.Meshes = []gltfMesh{{
+			Name:       frames[0].Name,
+			Primitives: g.primitives(all, idx, attrs, targets),
+			Weights:    make([]float32, len(frames)-1),
+		}}
+		doc.Nodes[0].Mesh = &mesh
+
+		weights := make([]float32, len(frames)*(len(frames)-1))
+		for f := 1; f < len(frames); f++ {
+			weights[f*(len(frames)-1)+f-1] = 1
+		}
+		anim.Samplers = []gltfAnimationSampler{{
+			Input:         g.floats(times, 1, "SCALAR", 0, true),
+			Interpolation: "STEP",
+			Output:        g.floats(weights, 1, "SCALAR", 0, false),
+		}}
+		anim.Channels = []gltfChannel{{Target: gltfChannelTarget{Node: 0, Path: "weights"}}}
+	}
+
+	if len(anim.Channels) > 0 {
+		doc.Animations = []gltfAnimation{anim}
+	}
+
+	for g.bin.Len()%4 != 0 {
+		g.bin.WriteByte(0)
+	}
+	doc.Buffers = []map[string]any{{"byteLength": g.bin.Len()}}
+
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+
+	// GLB container: header, JSON chunk, BIN chunk. The binary chunk has to
+	// be complete before the JSON describing it, so it is built in memory:
+	file := &errWriter{w: w}
+	_, _ = io.WriteString(file, "glTF")
+	_ = binary.Write(file, binary.LittleEndian, uint32(2))
+	_ = binary.Write(file, binary.LittleEndian, uint32(12+8+len(js)+8+g.bin.Len()))
+	_ = binary.Write(file, binary.LittleEndian, uint32(len(js)))
+	_, _ = io.WriteString(file, "JSON")
+	_, _ = file.Write(js)
+	_ = binary.Write(file, binary.LittleEndian, uint32(g.bin.Len()))
+	_, _ = io.WriteString(file, "BIN\x00")
+	_, _ = file.Write(g.bin.Bytes())
+
+	return file.err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// readGLB checks the GLB container and returns its JSON document and the
+// length of its binary chunk.
+func readGLB(t *testing.T, data []byte) (doc gltfDocument, binLength int) {
+	t.Helper()
+	le := binary.LittleEndian
+	if len(data) < 20 || string(data[:4]) != "glTF" {
+		t.Fatalf("no glTF header")
+	}
+	if v := le.Uint32(data[4:]); v != 2 {
+		t.Errorf("version %d, want 2", v)
+	}
+	if n := le.Uint32(data[8:]); int(n) != len(data) {
+		t.Errorf("header length %d, file is %d bytes", n, len(data))
+	}
+
+	jsLen := int(le.Uint32(data[12:]))
+	if string(data[16:20]) != "JSON" || jsLen%4 != 0 {
+		t.Fatalf("JSON chunk %q of %d bytes", data[16:20], jsLen)
+	}
+	if err := json.Unmarshal(data[20:20+jsLen], &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := data[20+jsLen:]
+	binLength = int(le.Uint32(bin))
+	if string(bin[4:8]) != "BIN\x00" || 8+binLength != len(bin) {
+		t.Fatalf("BIN chunk %q of %d bytes, %d left", bin[4:8], binLength, len(bin)-8)
+	}
+	return
+}
+
+func TestWriteGLB(t *testing.T) {
+	vol := NewVolume(3, 3, 3)
+	vol.Filled[1][1][1] = true
+	vol.Voxels[1][1][1] = 42
+	frame := meshFrame{Name: "CYBRA", Quads: vol.GreedyMesh(), Origin: [3]int{1, 1, 1}}
+
+	var b bytes.Buffer
+	if err := writeGLB(&b, []meshFrame{frame}, testPalette(), DefaultGLTFOptions()); err != nil {
+		t.Fatal(err)
+	}
+	doc, binLength := readGLB(t, b.Bytes())
+
+	if doc.Asset.Version != "2.0" {
+		t.Errorf("asset version %q", doc.Asset.Version)
+	}
+	if len(doc.Buffers) != 1 || doc.Buffers[0]["byteLength"] != float64(binLength) {
+		t.Errorf("buffers %v, BIN chunk is %d bytes", doc.Buffers, binLength)
+	}
+	// a root node and one frame node, one mesh and no animation:
+	if len(doc.Nodes) != 2 || len(doc.Meshes) != 1 || len(doc.Animations) != 0 {
+		t.Fatalf("%d nodes, %d meshes, %d animations", len(doc.Nodes), len(doc.Meshes), len(doc.Animations))
+	}
+	if doc.Nodes[1].Mesh == nil || *doc.Nodes[1].Mesh != 0 {
+		t.Errorf("frame node has mesh %v", doc.Nodes[1].Mesh)
+	}
+	if len(doc.Materials) != 1 || len(doc.Images) != 1 {
+		t.Errorf("%d materials, %d images", len(doc.Materials), len(doc.Images))
+	}
+
+	// six faces of four vertices and two triangles each:
+	prims := doc.Meshes[0].Primitives
+	if len(prims) != 1 {
+		t.Fatalf("%d primitives", len(prims))
+	}
+	for _, attr := range []string{"POSITION", "NORMAL", "TEXCOORD_0"} {
+		if n := doc.Accessors[prims[0].Attributes[attr]].Count; n != 24 {
+			t.Errorf("%s count %d, want 24", attr, n)
+		}
+	}
+	if n := doc.Accessors[prims[0].Indices].Count; n != 36 {
+		t.Errorf("index count %d, want 36", n)
+	}
+	pos := doc.Accessors[prims[0].Attributes["POSITION"]]
+	if len(pos.Min) != 3 || len(pos.Max) != 3 || pos.Min[1] != 0 || pos.Max[1] != 1 || pos.Min[2] != -1 || pos.Max[2] != 0 {
+		t.Errorf("POSITION bounds %v..%v", pos.Min, pos.Max)
+	}
+	for _, view := range doc.BufferViews {
+		if view.ByteOffset%4 != 0 || view.ByteOffset+view.ByteLength > binLength {
+			t.Errorf("buffer view at %d+%d outside the %d byte buffer", view.ByteOffset, view.ByteLength, binLength)
+		}
+	}
+
+	// two frames as morph targets share one mesh and animate its weights:
+	b.Reset()
+	opts := DefaultGLTFOptions()
+	opts.Frames = "morph"
+	if err := writeGLB(&b, []meshFrame{frame, frame}, testPalette(), opts); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ = readGLB(t, b.Bytes())
+	if len(doc.Meshes) != 1 || len(doc.Meshes[0].Primitives[0].Targets) != 1 || len(doc.Animations) != 1 {
+		t.Fatalf("morph: %d meshes, %d animations", len(doc.Meshes), len(doc.Animations))
+	}
+	if n := doc.Accessors[doc.Meshes[0].Primitives[0].Indices].Count; n != 72 {
+		t.Errorf("morph: index count %d, want 72", n)
+	}
+
+	if err := writeGLB(&b, []meshFrame{{Name: "CYBRA"}}, testPalette(), DefaultGLTFOptions()); err == nil {
+		t.Error("empty model written")
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+// InteriorOptions controls what happens to the voxels under the surface of
+// the carved volume.
+type InteriorOptions struct {
+	// Shell is the thickness in voxels of the surface to keep as is; the
+	// interior is everything deeper. 0 leaves the volume untouched.
+	Shell int
+	// Hollow removes the interior voxels.
+	Hollow bool
+	// FillColor, if >= 0, paints the interior voxels with this palette index
+	// when not hollowing.
+	FillColor int
+}
+
+func DefaultInteriorOptions() InteriorOptions {
+	return InteriorOptions{FillColor: -1}
+}
+
+// Cavity is a region of empty voxels fully enclosed by the model.
+type Cavity struct {
+	Min, Max [3]int
+	Size     int
+}
+
+// InteriorReport describes what ProcessInterior found and changed.
+type InteriorReport struct {
+	Cavities []Cavity
+	// Interior is the number of voxels deeper than the shell.
+	Interior int
+	Hollowed int
+	Filled   int
+}
+
+var neighbors6 = [6][3]int{
+	{-1, 0, 0}, {1, 0, 0},
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+}
+
+// index flattens a voxel coordinate for the flat per-voxel work arrays.
+func (vol *Volume) index(x, y, z int) int {
+	return (x*vol.MaxY+y)*vol.MaxZ + z
+}
+
+func (vol *Volume) coords(k int) (x, y, z int) {
+	z = k % vol.MaxZ
+	k /= vol.MaxZ
+	y = k % vol.MaxY
+	x = k / vol.MaxY
+	return
+}
+
+// Outside flood-fills the empty space reachable from the edges of the grid
+// and returns a flat per-voxel mask of it.
+func (vol *Volume) Outside() []bool {
+	outside := make([]bool, vol.MaxX*vol.MaxY*vol.MaxZ)
+	queue := make([]int, 0, 1024)
+
+	seed := func(x, y, z int) {
+		k := vol.index(x, y, z)
+		if !vol.Filled[x][y][z] && !outside[k] {
+			outside[k] = true
+			queue = append(queue, k)
+		}
+	}
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if x == 0 || y == 0 || z == 0 || x == vol.MaxX-1 || y == vol.MaxY-1 || z == vol.MaxZ-1 {
+					seed(x, y, z)
+				}
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		x, y, z := vol.coords(k)
+		for _, d := range neighbors6 {
+			nx, ny, nz := x+d[0], y+d[1], z+d[2]
+			if !vol.Contains(nx, ny, nz) {
+				continue
+			}
+			seed(nx, ny, nz)
+		}
+	}
+
+	return outside
+}
+
+// FindCavities returns the enclosed empty regions of the volume, given the
+// mask returned by Outside.
+func (vol *Volume) FindCavities(outside []bool) (cavities []Cavity) {
+	visited := make([]bool, len(outside))
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				k := vol.index(x, y, z)
+				if vol.Filled[x][y][z] || outside[k] || visited[k] {
+					continue
+				}
+
+				cavity := Cavity{Min: [3]int{x, y, z}, Max: [3]int{x, y, z}}
+				visited[k] = true
+				queue := []int{k}
+				for len(queue) > 0 {
+					c := queue[0]
+					queue = queue[1:]
+					cx, cy, cz := vol.coords(c)
+					cavity.Size++
+					p := [3]int{cx, cy, cz}
+					for a := 0; a < 3; a++ {
+						if p[a] < cavity.Min[a] {
+							cavity.Min[a] = p[a]
+						}
+						if p[a] > cavity.Max[a] {
+							cavity.Max[a] = p[a]
+						}
+					}
+
+					for _, d := range neighbors6 {
+						nx, ny, nz := cx+d[0], cy+d[1], cz+d[2]
+						if !vol.Contains(nx, ny, nz) || vol.Filled[nx][ny][nz] {
+							continue
+						}
+						n := vol.index(nx, ny, nz)
+						if outside[n] || visited[n] {
+							continue
+						}
+						visited[n] = true
+						queue = append(queue, n)
+					}
+				}
+
+				cavities = append(cavities, cavity)
+			}
+		}
+	}
+
+	return
+}
+
+// Depth returns the 6-connected distance of each filled voxel from the
+// outside, starting at 1 for voxels on the outer surface; empty voxels are 0.
+func (vol *Volume) Depth(outside []bool) []uint16 {
+	depth := make([]uint16, len(outside))
+	queue := make([]int, 0, 1024)
+
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if !vol.Filled[x][y][z] {
+					continue
+				}
+				surface := false
+				for _, d := range neighbors6 {
+					nx, ny, nz := x+d[0], y+d[1], z+d[2]
+					if !vol.Contains(nx, ny, nz) || outside[vol.index(nx, ny, nz)] {
+						surface = true
+						break
+					}
+				}
+				if surface {
+					k := vol.index(x, y, z)
+					depth[k] = 1
+					queue = append(queue, k)
+				}
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		x, y, z := vol.coords(k)
+		for _, d := range neighbors6 {
+			nx, ny, nz := x+d[0], y+d[1], z+d[2]
+			if !vol.Contains(nx, ny, nz) || !vol.Filled[nx][ny][nz] {
+				continue
+			}
+			n := vol.index(nx, ny, nz)
+			if depth[n] == 0 {
+				depth[n] = depth[k] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	return depth
+}
+
+// ProcessInterior reports enclosed cavities and hollows out or recolors the
+// voxels deeper than opts.Shell.
+func (vol *Volume) ProcessInterior(opts InteriorOptions) (report InteriorReport) {
+	outside := vol.Outside()
+	report.Cavities = vol.FindCavities(outside)
+
+	if opts.Shell <= 0 {
+		return
+	}
+
+	depth := vol.Depth(outside)
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				// voxels only reachable through cavities have no depth and
+				// count as interior:
+				if !vol.Filled[x][y][z] {
+					continue
+				}
+				d := depth[vol.index(x, y, z)]
+				if d != 0 && int(d) <= opts.Shell {
+					continue
+				}
+
+				report.Interior++
+				if opts.Hollow {
+					vol.Filled[x][y][z] = false
+					report.Hollowed++
+				} else if opts.FillColor >= 0 {
+					vol.Voxels[x][y][z] = uint8(opts.FillColor)
+					report.Filled++
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+// testBox returns a solid 5x5x5 cube inside a 7x7x7 grid, with an enclosed
+// empty voxel at its center when cavity is set.
+func testBox(cavity bool) *Volume {
+	vol := NewVolume(7, 7, 7)
+	for x := 1; x <= 5; x++ {
+		for y := 1; y <= 5; y++ {
+			for z := 1; z <= 5; z++ {
+				vol.Filled[x][y][z] = true
+				vol.Voxels[x][y][z] = 10
+			}
+		}
+	}
+	if cavity {
+		vol.Filled[3][3][3] = false
+	}
+	return vol
+}
+
+func TestFindCavities(t *testing.T) {
+	vol := testBox(true)
+	cavities := vol.FindCavities(vol.Outside())
+	if len(cavities) != 1 {
+		t.Fatalf("found %d cavities, want 1", len(cavities))
+	}
+	want := Cavity{Min: [3]int{3, 3, 3}, Max: [3]int{3, 3, 3}, Size: 1}
+	if cavities[0] != want {
+		t.Errorf("cavity = %+v, want %+v", cavities[0], want)
+	}
+
+	if cavities := testBox(false).FindCavities(testBox(false).Outside()); len(cavities) != 0 {
+		t.Errorf("solid box has %d cavities", len(cavities))
+	}
+}
+
+func TestProcessInterior(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     InteriorOptions
+		interior int
+		count    int
+		center   uint8
+	}{
+		// a shell of 1 leaves the 3x3x3 core as interior:
+		{"report only", InteriorOptions{Shell: 1, FillColor: -1}, 27, 125, 10},
+		{"hollow", InteriorOptions{Shell: 1, Hollow: true, FillColor: -1}, 27, 98, 10},
+		{"fill", InteriorOptions{Shell: 1, FillColor: 7}, 27, 125, 7},
+		// a shell of 2 leaves only the center:
+		{"thick fill", InteriorOptions{Shell: 2, FillColor: 7}, 1, 125, 7},
+		{"no shell", InteriorOptions{Shell: 0, Hollow: true, FillColor: -1}, 0, 125, 10},
+	}
+	for _, tt := range tests {
+		vol := testBox(false)
+		report := vol.ProcessInterior(tt.opts)
+		if report.Interior != tt.interior {
+			t.Errorf("%s: %d interior voxels, want %d", tt.name, report.Interior, tt.interior)
+		}
+		if n := vol.Count(); n != tt.count {
+			t.Errorf("%s: %d voxels left, want %d", tt.name, n, tt.count)
+		}
+		if vol.Filled[3][3][3] && vol.Voxels[3][3][3] != tt.center {
+			t.Errorf("%s: center color %d, want %d", tt.name, vol.Voxels[3][3][3], tt.center)
+		}
+		// the surface is never touched:
+		if !vol.Filled[1][1][1] || vol.Voxels[1][1][1] != 10 {
+			t.Errorf("%s: surface voxel changed", tt.name)
+		}
+	}
+
+	// fill does not reach past the shell:
+	vol := testBox(false)
+	report := vol.ProcessInterior(InteriorOptions{Shell: 2, FillColor: 7})
+	if report.Filled != 1 || vol.Voxels[2][3][3] != 10 {
+		t.Errorf("shell 2 filled %d voxels, (2,3,3) is %d", report.Filled, vol.Voxels[2][3][3])
+	}
+
+	// a cavity is reported, and the voxels around it are still interior:
+	vol = testBox(true)
+	report = vol.ProcessInterior(InteriorOptions{Shell: 1, FillColor: 7})
+	if len(report.Cavities) != 1 || report.Interior != 26 || vol.Voxels[2][3][3] != 7 {
+		t.Errorf("with a cavity: %d cavities, %d interior, (2,3,3) is %d", len(report.Cavities), report.Interior, vol.Voxels[2][3][3])
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// KV6 visibility bits, set for faces whose neighbor is empty:
+const (
+	kv6Left   = 1 << iota // -x
+	kv6Right              // +x
+	kv6Back               // -y
+	kv6Front              // +y
+	kv6Top                // -z
+	kv6Bottom             // +z
+)
+
+// kv6Faces pairs each neighbor offset, in KV6 coordinates, with its bit.
+var kv6Faces = [6]struct {
+	d   [3]int
+	bit uint8
+}{
+	{[3]int{-1, 0, 0}, kv6Left},
+	{[3]int{1, 0, 0}, kv6Right},
+	{[3]int{0, -1, 0}, kv6Back},
+	{[3]int{0, 1, 0}, kv6Front},
+	{[3]int{0, 0, -1}, kv6Top},
+	{[3]int{0, 0, 1}, kv6Bottom},
+}
+
+// kv6Dirs holds the 255 surface normal directions KV6 quantizes to, spread
+// evenly over the sphere the same way as Ken Silverman's equivec table.
+var kv6Dirs = func() (dirs [255][3]float64) {
+	const goldenRatio = 0.3819660112501052 // 1 - 1/phi
+	zmul := 2.0 / 255.0
+	zadd := zmul*0.5 - 1.0
+	for i := range dirs {
+		z := float64(i)*zmul + zadd
+		r := math.Sqrt(1 - z*z)
+		a := float64(i) * goldenRatio * math.Pi * 2
+		dirs[i] = [3]float64{math.Cos(a) * r, math.Sin(a) * r, z}
+	}
+	return
+}()
+
+// kv6Dir returns the index of the table direction closest to n.
+func kv6Dir(n [3]float64) uint8 {
+	best, bestDot := 0, math.Inf(-1)
+	for i, d := range kv6Dirs {
+		if dot := d[0]*n[0] + d[1]*n[1] + d[2]*n[2]; dot > bestDot {
+			best, bestDot = i, dot
+		}
+	}
+	return uint8(best)
+}
+
+func saveKV6(path string, vol *Volume, pal color.Palette) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		return writeKV6(w, vol, pal)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeKV6 writes the volume as a KV6 file. KV6 stores only surface voxels,
+// with Z pointing down; Y is flipped as well to keep the axes right-handed,
+// so the model faces +Y. The pivot is the actor's pivot at ground level.
+func writeKV6(w io.Writer, vol *Volume, pal color.Palette) error {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return fmt.Errorf("model is empty")
+	}
+	xsiz, ysiz, zsiz := hi[0]-lo[0]+1, hi[1]-lo[1]+1, hi[2]-lo[2]+1
+
+	// KV6 coordinates of a voxel:
+	at := func(kx, ky, kz int) (x, y, z int) {
+		return lo[0] + kx, hi[1] - ky, hi[2] - kz
+	}
+
+	// the voxel count comes first in the file, so collect the surface
+	// voxel records before writing:
+	voxels := &bytes.Buffer{}
+	numVoxels := 0
+	xlen := make([]uint32, xsiz)
+	ylen := make([]uint16, xsiz*ysiz)
+	for kx := 0; kx < xsiz; kx++ {
+		for ky := 0; ky < ysiz; ky++ {
+			for kz := 0; kz < zsiz; kz++ {
+				x, y, z := at(kx, ky, kz)
+				if !vol.Filled[x][y][z] {
+					continue
+				}
+
+				var vis uint8
+				for _, f := range kv6Faces {
+					nx, ny, nz := at(kx+f.d[0], ky+f.d[1], kz+f.d[2])
+					if !vol.IsFilled(nx, ny, nz) {
+						vis |= f.bit
+					}
+				}
+				if vis == 0 {
+					continue
+				}
+
+				// the normal points towards the empty neighbors:
+				var n [3]float64
+				for dx := -1; dx <= 1; dx++ {
+					for dy := -1; dy <= 1; dy++ {
+						for dz := -1; dz <= 1; dz++ {
+							nx, ny, nz := at(kx+dx, ky+dy, kz+dz)
+							if !vol.IsFilled(nx, ny, nz) {
+								n[0] += float64(dx)
+								n[1] += float64(dy)
+								n[2] += float64(dz)
+							}
+						}
+					}
+				}
+				if l := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2]); l > 0 {
+					n = [3]float64{n[0] / l, n[1] / l, n[2] / l}
+				}
+
+				c := rgbaOf(pal[vol.Voxels[x][y][z]])
+				// color is BGR with 128 as the alpha (brightness) byte:
+				voxels.Write([]byte{c.B, c.G, c.R, 128})
+				_ = binary.Write(voxels, binary.LittleEndian, uint16(kz))
+				voxels.WriteByte(vis)
+				voxels.WriteByte(kv6Dir(n))
+
+				numVoxels++
+				xlen[kx]++
+				ylen[kx*ysiz+ky]++
+			}
+		}
+	}
+
+	file := &errWriter{w: w}
+	_, _ = io.WriteString(file, "Kvxl")
+	for _, v := range []int{xsiz, ysiz, zsiz} {
+		_ = binary.Write(file, binary.LittleEndian, int32(v))
+	}
+	pivot := []float32{
+		float32(vol.Pivot.X - float64(lo[0])),
+		float32(float64(hi[1]+1) - vol.Pivot.Y),
+		float32(float64(hi[2]+1) - vol.Pivot.Z),
+	}
+	_ = binary.Write(file, binary.LittleEndian, pivot)
+	_ = binary.Write(file, binary.LittleEndian, int32(numVoxels))
+	_, _ = file.Write(voxels.Bytes())
+	_ = binary.Write(file, binary.LittleEndian, xlen)
+	_ = binary.Write(file, binary.LittleEndian, ylen)
+
+	return file.err
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestWriteKV6(t *testing.T) {
+	pal := testPalette()
+	vol := NewVolume(4, 4, 4)
+	vol.Pivot.X, vol.Pivot.Y = 1.5, 0.5
+	vol.Filled[2][1][3] = true
+	vol.Voxels[2][1][3] = 42
+
+	var b bytes.Buffer
+	if err := writeKV6(&b, vol, pal); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	le := binary.LittleEndian
+
+	// header, pivot, count, one 8-byte voxel, xlen and ylen:
+	if len(data) != 4+12+12+4+8+4+2 {
+		t.Fatalf("KV6 is %d bytes, want 46", len(data))
+	}
+	if string(data[:4]) != "Kvxl" {
+		t.Errorf("magic %q", data[:4])
+	}
+	for a := 0; a < 3; a++ {
+		if size := le.Uint32(data[4+a*4:]); size != 1 {
+			t.Errorf("size %d = %d, want 1", a, size)
+		}
+	}
+	// Y and Z are flipped within the one voxel box:
+	wantPivot := [3]float32{-0.5, 1.5, 4}
+	for a := 0; a < 3; a++ {
+		if p := math.Float32frombits(le.Uint32(data[16+a*4:])); p != wantPivot[a] {
+			t.Errorf("pivot %d = %g, want %g", a, p, wantPivot[a])
+		}
+	}
+	if n := le.Uint32(data[28:]); n != 1 {
+		t.Errorf("%d voxels, want 1", n)
+	}
+
+	c := rgbaOf(pal[42])
+	voxel := data[32:40]
+	if !bytes.Equal(voxel[:4], []byte{c.B, c.G, c.R, 128}) {
+		t.Errorf("voxel color % x, want BGR of %v", voxel[:4], c)
+	}
+	if z := le.Uint16(voxel[4:]); z != 0 {
+		t.Errorf("voxel z = %d", z)
+	}
+	if vis := voxel[6]; vis != kv6Left|kv6Right|kv6Back|kv6Front|kv6Top|kv6Bottom {
+		t.Errorf("visibility %#x, want all faces", vis)
+	}
+	if xlen, ylen := le.Uint32(data[40:]), le.Uint16(data[44:]); xlen != 1 || ylen != 1 {
+		t.Errorf("xlen %d, ylen %d", xlen, ylen)
+	}
+
+	// a solid 3x3x3 block stores its 26 surface voxels, not the hidden one:
+	vol = NewVolume(4, 4, 4)
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			for z := 0; z < 3; z++ {
+				vol.Filled[x][y][z] = true
+			}
+		}
+	}
+	b.Reset()
+	if err := writeKV6(&b, vol, pal); err != nil {
+		t.Fatal(err)
+	}
+	data = b.Bytes()
+	if n := le.Uint32(data[28:]); n != 26 {
+		t.Errorf("solid block: %d voxels, want 26", n)
+	}
+	if len(data) != 32+26*8+3*4+9*2 {
+		t.Errorf("solid block: KV6 is %d bytes", len(data))
+	}
+}
+
+func TestKV6Dir(t *testing.T) {
+	for _, n := range [][3]float64{{1, 0, 0}, {0, -1, 0}, {0, 0, 1}} {
+		d := kv6Dirs[kv6Dir(n)]
+		if dot := d[0]*n[0] + d[1]*n[1] + d[2]*n[2]; dot < 0.95 {
+			t.Errorf("kv6Dir(%v) = %v", n, d)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// namespaceMarkers maps the start and end markers of each namespace to its
+// name; PWADs may double the letter, e.g. SS_START.
+var namespaceMarkers = map[string]string{
+	"S_START": "sprites", "S_END": "sprites", "SS_START": "sprites", "SS_END": "sprites",
+	"F_START": "flats", "F_END": "flats", "FF_START": "flats", "FF_END": "flats",
+	"F1_START": "flats", "F1_END": "flats", "F2_START": "flats", "F2_END": "flats", "F3_START": "flats", "F3_END": "flats",
+	"P_START": "patches", "P_END": "patches", "PP_START": "patches", "PP_END": "patches",
+	"P1_START": "patches", "P1_END": "patches", "P2_START": "patches", "P2_END": "patches", "P3_START": "patches", "P3_END": "patches",
+	"C_START": "colormaps", "C_END": "colormaps",
+	"TX_START": "textures", "TX_END": "textures",
+	"HI_START": "hires", "HI_END": "hires",
+}
+
+// mapLumps are the lumps that follow a map marker such as MAP01 or E1M1.
+var mapLumps = map[string]bool{
+	"THINGS": true, "LINEDEFS": true, "SIDEDEFS": true, "VERTEXES": true, "SEGS": true,
+	"SSECTORS": true, "NODES": true, "SECTORS": true, "REJECT": true, "BLOCKMAP": true,
+	"BEHAVIOR": true, "SCRIPTS": true, "TEXTMAP": true, "ZNODES": true, "DIALOGUE": true, "ENDMAP": true,
+}
+
+// lumpEntry is one lump as the list command shows it.
+type lumpEntry struct {
+	WAD       string `json:"wad"`
+	Index     int    `json:"index"`
+	Namespace string `json:"namespace"`
+	Map       string `json:"map,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Size      int    `json:"size"`
+	// Winner is the WAD whose lump of this name and namespace the engine
+	// uses, the entry's own WAD if it wins.
+	Winner string `json:"winner"`
+}
+
+// listLumps returns the lumps of all WADs in load order, typed by their
+// namespace, name and contents, with the WAD that wins each name.
+func listLumps(wc *WADCollection) (entries []lumpEntry) {
+	winners := map[string]string{}
+	keys := []string{}
+	for i := len(wc.Ordered) - 1; i >= 0; i-- {
+		wad := wc.Ordered[i]
+		namespace, mapName := "global", ""
+		for j := range wad.Lumps {
+			lump := &wad.Lumps[j]
+			e := lumpEntry{WAD: wad.Name, Index: j, Name: lump.Name, Size: len(lump.Data)}
+
+			if ns, ok := namespaceMarkers[lump.Name]; ok {
+				e.Namespace = ns
+				if strings.HasSuffix(lump.Name, "_START") {
+					namespace = ns
+				} else {
+					namespace = "global"
+				}
+				mapName = ""
+			} else if mapLumps[lump.Name] && mapName != "" {
+				e.Namespace, e.Map = "maps", mapName
+			} else {
+				e.Namespace = namespace
+				mapName = ""
+				if namespace == "global" && j+1 < len(wad.Lumps) && mapLumps[wad.Lumps[j+1].Name] {
+					e.Namespace, e.Map, mapName = "maps", lump.Name, lump.Name
+				}
+			}
+			e.Type = sniffLump(e.Namespace, e.Map != "" && e.Map != e.Name, lump)
+
+			// later lumps of a name override earlier ones, also within a WAD:
+			key := e.Namespace + "/" + e.Map + "/" + e.Name
+			winners[key] = wad.Name
+			keys = append(keys, key)
+			entries = append(entries, e)
+		}
+	}
+	for i := range entries {
+		entries[i].Winner = winners[keys[i]]
+	}
+	return
+}
+
+// sniffLump names the type of a lump: marker, map, png, patch, flat, sound,
+// music, palette, colormap, text or data.
+func sniffLump(namespace string, mapData bool, lump *Lump) string {
+	b := lump.Data
+	switch {
+	case len(b) == 0:
+		return "marker"
+	case mapData:
+		return "map"
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(b, []byte("MUS\x1a")), bytes.HasPrefix(b, []byte("MThd")),
+		bytes.HasPrefix(b, []byte("OggS")), bytes.HasPrefix(b, []byte("fLaC")), bytes.HasPrefix(b, []byte("ID3")):
+		return "music"
+	case bytes.HasPrefix(b, []byte("RIFF")) && len(b) >= 12 && string(b[8:12]) == "WAVE":
+		return "sound"
+	case lump.Name == "PLAYPAL":
+		return "palette"
+	case lump.Name == "COLORMAP" || namespace == "colormaps":
+		return "colormap"
+	case namespace == "flats" && (len(b) == 64*64 || len(b) == 64*128):
+		return "flat"
+	case isDoomSound(b):
+		return "sound"
+	case isPatch(b):
+		return "patch"
+	case isText(b):
+		return "text"
+	}
+	return "data"
+}
+
+// isDoomSound tells whether b is in the DMX format of Doom's DS* lumps.
+func isDoomSound(b []byte) bool {
+	if len(b) < 8 || le.Uint16(b[0:2]) != 3 {
+		return false
+	}
+	rate := le.Uint16(b[2:4])
+	samples := le.Uint32(b[4:8])
+	return rate >= 4000 && rate <= 48000 && int64(samples) <= int64(len(b)-8)
+}
+
+// isPatch tells whether b is a patch in Doom's picture format: a plausible
+// header and column offsets that all point into the lump.
+func isPatch(b []byte) bool {
+	if len(b) < 8 {
+		return false
+	}
+	width := int(le.Uint16(b[0:2]))
+	height := int(le.Uint16(b[2:4]))
+	if width == 0 || width > 4096 || height == 0 || height > 4096 || len(b) < 8+width*4 {
+		return false
+	}
+	for i := 0; i < width; i++ {
+		offs := int(le.Uint32(b[8+i*4:]))
+		if offs < 8+width*4 || offs >= len(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// isText tells whether b holds printable ASCII text only.
+func isText(b []byte) bool {
+	for _, c := range b {
+		if (c < 0x20 || c > 0x7e) && c != '\n' && c != '\r' && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// runList implements the list command: it prints the lumps of the loaded
+// WADs that pass the filters in args as a table, JSON or CSV.
+func runList(wc *WADCollection, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	namespace := fs.String("ns", "", "only lumps in this namespace: global, sprites, flats, patches, colormaps, textures, hires or maps")
+	name := fs.String("name", "", "only lumps whose name matches this glob, e.g. TROO*")
+	types := fs.String("type", "", "comma-separated lump types to show: marker, map, png, patch, flat, sound, music, palette, colormap, text or data")
+	minSize := fs.Int("min-size", 0, "only lumps of at least this many bytes")
+	maxSize := fs.Int("max-size", -1, "only lumps of at most this many bytes; -1 for no limit")
+	winners := fs.Bool("winners", false, "only the lumps the engine uses, leaving out overridden ones")
+	format := fs.String("format", "table", "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("list: unexpected argument %q", fs.Arg(0))
+	}
+	if *name != "" {
+		if _, err := path.Match(*name, ""); err != nil {
+			return fmt.Errorf("list: -name: %w", err)
+		}
+	}
+	wanted := map[string]bool{}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			wanted[strings.ToLower(t)] = true
+		}
+	}
+
+	var shown []lumpEntry
+	for _, e := range listLumps(wc) {
+		if *namespace != "" && e.Namespace != strings.ToLower(*namespace) {
+			continue
+		}
+		if ok, _ := path.Match(strings.ToUpper(*name), e.Name); *name != "" && !ok {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[e.Type] {
+			continue
+		}
+		if e.Size < *minSize || (*maxSize >= 0 && e.Size > *maxSize) {
+			continue
+		}
+		if *winners && e.Winner != e.WAD {
+			continue
+		}
+		shown = append(shown, e)
+	}
+
+	switch *format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "WAD\tINDEX\tNAMESPACE\tNAME\tTYPE\tSIZE\tWINNER")
+		for _, e := range shown {
+			name := e.Name
+			if e.Map != "" && e.Map != e.Name {
+				name = e.Map + "/" + e.Name
+			}
+			winner := "yes"
+			if e.Winner != e.WAD {
+				winner = e.Winner
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\t%s\n", e.WAD, e.Index, e.Namespace, name, e.Type, e.Size, winner)
+		}
+		return tw.Flush()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if shown == nil {
+			shown = []lumpEntry{}
+		}
+		return enc.Encode(shown)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"wad", "index", "namespace", "map", "name", "type", "size", "winner"})
+		for _, e := range shown {
+			_ = cw.Write([]string{e.WAD, strconv.Itoa(e.Index), e.Namespace, e.Map, e.Name, e.Type, strconv.Itoa(e.Size), e.Winner})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("list: -format must be table, json or csv, got %q", *format)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestListLumps(t *testing.T) {
+	dmx := []byte{3, 0, 0x11, 0x2B, 4, 0, 0, 0, 0x80, 0x80, 0x80, 0x80}
+	iwad := testLumpWAD("iwad.wad",
+		Lump{Name: "PLAYPAL", Data: make([]byte, 768)},
+		Lump{Name: "COLORMAP", Data: make([]byte, 256)},
+		Lump{Name: "MAP01"},
+		Lump{Name: "THINGS", Data: make([]byte, 10)},
+		Lump{Name: "LINEDEFS", Data: []byte("not text\x00")},
+		Lump{Name: "DEMO", Data: []byte("hello\n")},
+		Lump{Name: "S_START"},
+		testPatchLump("TROOA1", 2),
+		Lump{Name: "S_END"},
+		Lump{Name: "F_START"},
+		Lump{Name: "FLOOR0_1", Data: make([]byte, 64*64)},
+		Lump{Name: "F_END"},
+		Lump{Name: "DSPISTOL", Data: dmx},
+		Lump{Name: "D_E1M1", Data: []byte("MUS\x1a\x00\x00")},
+	)
+	pwad := testLumpWAD("pwad.wad",
+		Lump{Name: "SS_START"},
+		testPatchLump("TROOA1", 3),
+		Lump{Name: "SS_END"},
+		Lump{Name: "TITLEPIC", Data: []byte("\x89PNG\r\n\x1a\n\x00")},
+		Lump{Name: "DSWAV", Data: []byte("RIFF\x00\x00\x00\x00WAVEfmt ")},
+		Lump{Name: "BLOB", Data: []byte{0, 1, 2}},
+	)
+	wc := &WADCollection{Ordered: []*WAD{pwad, iwad}}
+
+	want := []lumpEntry{
+		{WAD: "iwad.wad", Namespace: "global", Name: "PLAYPAL", Type: "palette"},
+		{WAD: "iwad.wad", Namespace: "global", Name: "COLORMAP", Type: "colormap"},
+		{WAD: "iwad.wad", Namespace: "maps", Map: "MAP01", Name: "MAP01", Type: "marker"},
+		// map lumps are typed by where they are, not what they hold:
+		{WAD: "iwad.wad", Namespace: "maps", Map: "MAP01", Name: "THINGS", Type: "map"},
+		{WAD: "iwad.wad", Namespace: "maps", Map: "MAP01", Name: "LINEDEFS", Type: "map"},
+		{WAD: "iwad.wad", Namespace: "global", Name: "DEMO", Type: "text"},
+		{WAD: "iwad.wad", Namespace: "sprites", Name: "S_START", Type: "marker"},
+		{WAD: "iwad.wad", Namespace: "sprites", Name: "TROOA1", Type: "patch", Winner: "pwad.wad"},
+		{WAD: "iwad.wad", Namespace: "sprites", Name: "S_END", Type: "marker"},
+		{WAD: "iwad.wad", Namespace: "flats", Name: "F_START", Type: "marker"},
+		{WAD: "iwad.wad", Namespace: "flats", Name: "FLOOR0_1", Type: "flat"},
+		{WAD: "iwad.wad", Namespace: "flats", Name: "F_END", Type: "marker"},
+		{WAD: "iwad.wad", Namespace: "global", Name: "DSPISTOL", Type: "sound"},
+		{WAD: "iwad.wad", Namespace: "global", Name: "D_E1M1", Type: "music"},
+		{WAD: "pwad.wad", Namespace: "sprites", Name: "SS_START", Type: "marker"},
+		{WAD: "pwad.wad", Namespace: "sprites", Name: "TROOA1", Type: "patch"},
+		{WAD: "pwad.wad", Namespace: "sprites", Name: "SS_END", Type: "marker"},
+		{WAD: "pwad.wad", Namespace: "global", Name: "TITLEPIC", Type: "png"},
+		{WAD: "pwad.wad", Namespace: "global", Name: "DSWAV", Type: "sound"},
+		{WAD: "pwad.wad", Namespace: "global", Name: "BLOB", Type: "data"},
+	}
+
+	got := listLumps(wc)
+	if len(got) != len(want) {
+		t.Fatalf("%d lumps, want %d", len(got), len(want))
+	}
+	index := map[string]int{}
+	for i, w := range want {
+		w.Index = index[w.WAD]
+		index[w.WAD]++
+		if w.Winner == "" {
+			w.Winner = w.WAD
+		}
+		w.Size = got[i].Size
+		if got[i] != w {
+			t.Errorf("lump %d = %+v, want %+v", i, got[i], w)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := runList(wc, []string{"-type", "patch", "-winners", "-format", "json"}, &b); err != nil {
+		t.Fatal(err)
+	}
+	var shown []lumpEntry
+	if err := json.Unmarshal(b.Bytes(), &shown); err != nil {
+		t.Fatal(err)
+	}
+	if len(shown) != 1 || shown[0].WAD != "pwad.wad" || shown[0].Name != "TROOA1" {
+		t.Errorf("winning patches: %+v", shown)
+	}
+
+	if err := runList(wc, []string{"-format", "xml"}, &b); err == nil {
+		t.Error("format xml accepted")
+	}
+}
+
+func TestSniffLump(t *testing.T) {
+	tests := []struct {
+		namespace string
+		name      string
+		data      []byte
+		want      string
+	}{
+		{"global", "GENMIDI", []byte("MThd\x00\x00\x00\x06"), "music"},
+		{"global", "D_RUNNIN", []byte("OggS\x00"), "music"},
+		// a flat's size only counts in the flats namespace:
+		{"global", "FLOOR0_1", make([]byte, 64*64), "data"},
+		{"flats", "FLOOR0_1", make([]byte, 64*128), "flat"},
+		{"colormaps", "WATERMAP", make([]byte, 10), "colormap"},
+		// a DMX header with more samples than the lump holds:
+		{"global", "DSBAD", []byte{3, 0, 0x11, 0x2B, 100, 0, 0, 0, 0x80}, "data"},
+		{"sprites", "TROOA1", testPatchLump("TROOA1", 4).Data, "patch"},
+		// a column offset past the end of the lump:
+		{"sprites", "TROOA1", []byte{1, 0, 1, 0, 0, 0, 0, 0, 0xFF, 0, 0, 0}, "data"},
+		{"global", "DECORATE", []byte("actor Foo\r\n{\t}\n"), "text"},
+	}
+	for _, tt := range tests {
+		if got := sniffLump(tt.namespace, false, &Lump{Name: tt.name, Data: tt.data}); got != tt.want {
+			t.Errorf("%s/%s: %s, want %s", tt.namespace, tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,65 +1,426 @@
 package main
 
 import (
+	"awesomeProject/decorate"
 	"awesomeProject/matrix4"
+	"awesomeProject/palette"
 	"awesomeProject/vector3"
-	"bytes"
-	"encoding/binary"
+	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
+	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var wc WADCollection
 
+// game is the game of the loaded IWAD.
+var game *Game
+
+// translationTables holds the game's player translation table lumps.
+var translationTables map[string]palette.Translation
+
+var (
+	flagPreview     = flag.Bool("preview", true, "render pv-*.png previews and tt-*.gif turntables of each model")
+	flagPreviewSize = flag.Int("preview-size", 256, "width and height of preview renders in pixels")
+	flagYaw         = flag.Float64("yaw", 30, "preview camera angle around the model in degrees; 0 is the front")
+	flagPitch       = flag.Float64("pitch", 15, "preview camera elevation in degrees")
+	flagPerspective = flag.Bool("perspective", false, "use a perspective camera for previews instead of orthographic")
+	flagTurntable   = flag.Int("turntable", 36, "number of frames in the turntable GIF; 0 disables it")
+	flagIWAD        = flag.String("iwad", "", "IWAD to take sprites and palette from; defaults to the first known IWAD in $DOOMWADDIR")
+	flagFiles       = flag.String("file", "", "comma-separated PWADs loaded over the IWAD; defaults to the game's sprite fixes in $DOOMWADDIR, e.g. D2SPFX20.WAD for Doom II")
+	flagSpriteWAD   = flag.String("sprite-wad", "", "render each model back into Doom sprites and write them to this PWAD")
+	flagRotations   = flag.Int("rotations", 8, "number of sprite rotations to render for -sprite-wad: 8 or 16")
+	flagCarveVotes  = flag.Int("carve-votes", 1, "number of views that must see through a voxel to carve it away")
+	flagCarveFrac   = flag.Float64("carve-fraction", 0, "if > 0, carve a voxel once views with this fraction of the total weight see through it")
+	flagCarveWeight = flag.String("carve-weights", "", "comma-separated weights of rotations 1-8 for -carve-fraction")
+	flagColor       = flag.String("color", "last", "surface color strategy: last, normal, average, median or mode")
+	flagShell       = flag.Int("shell", 0, "thickness in voxels of the surface shell; deeper voxels are hollowed or filled")
+	flagHollow      = flag.Bool("hollow", false, "remove the voxels deeper than -shell")
+	flagFillColor   = flag.Int("fill-color", -1, "palette index to paint the voxels deeper than -shell with")
+	flagConnect     = flag.Int("connectivity", 26, "voxel connectivity for -min-component: 6 or 26")
+	flagMinComp     = flag.Int("min-component", 0, "remove connected components with fewer voxels than this after carving")
+	flagOpen        = flag.Int("open", 0, "kernel radius of a morphological opening after carving, to remove spikes")
+	flagClose       = flag.Int("close", 0, "kernel radius of a morphological closing after carving, to fill pits")
+	flagKernel      = flag.String("kernel", "cube", "kernel shape for -open and -close: cube or sphere")
+	flagSymmetry    = flag.String("symmetry", "off", "bilateral symmetry prior: off, axis (mirror across the sprite's facing axis) or detect")
+	flagSymSearch   = flag.Int("symmetry-search", 8, "how far in voxels -symmetry detect searches either side of the facing axis")
+	flagSymMerge    = flag.String("symmetry-merge", "union", "how mirrored halves are merged: union or intersect")
+	flagSymColor    = flag.String("symmetry-color", "keep", "colors of voxels present on both sides: keep or average")
+	flagVoxIn       = flag.String("vox-in", "", "load this MagicaVoxel file instead of voxelizing sprites, and export it like a voxelized frame")
+	flagVoxFrame    = flag.Int("vox-frame", 0, "animation frame to load from -vox-in")
+	flagVoxName     = flag.String("vox-name", "", "sprite name and frame for -vox-in outputs, e.g. CYBRA; defaults to the file name")
+	flagOBJ         = flag.Bool("obj", false, "also write each model as a greedy-meshed mdl-*.obj with an MTL and palette texture")
+	flagPLY         = flag.Bool("ply", false, "also write each model as a greedy-meshed mdl-*.ply with vertex colors")
+	flagGLB         = flag.Bool("glb", false, "also write each model as a greedy-meshed mdl-*.glb (glTF 2.0, Y up)")
+	flagGLBFrames   = flag.String("glb-frames", "nodes", "how -animated stores frames in anim-*.glb: nodes or morph (targets)")
+	flagGLBTime     = flag.Float64("glb-frame-time", 0.25, "seconds per frame in anim-*.glb animations")
+	flagMD3         = flag.Bool("md3", false, "write all frames of each sprite as a vertex-animated models/NAME/NAME.md3 with a skin and a modeldef.NAME.txt")
+	flagQB          = flag.Bool("qb", false, "also write each model as a Qubicle mdl-*.qb")
+	flagKV6         = flag.Bool("kv6", false, "also write each model as a KV6 mdl-*.kv6")
+	flagPlaypal     = flag.Int("playpal", 0, "PLAYPAL palette to use, 0-13 in Doom")
+	flagLight       = flag.Int("light", -1, "bake COLORMAP table 0-31 (light level, 0 brightest) or 32 (invulnerability) into exported colors; -1 exports the plain palette")
+	flagBrightCols  = flag.String("bright-colors", "", "palette indices that glow, e.g. 224-231,250; exported as emissive")
+	flagBrightCM    = flag.Bool("bright-colormap", false, "make the palette indices every COLORMAP light level leaves unchanged glow")
+	flagBrightFrms  = flag.String("bright-frames", "", "comma-separated sprites or sprite frames drawn fullbright, e.g. VILEF,CYBR; their whole model glows")
+	flagBrightmaps  = flag.String("brightmaps", "", "directory of sprite brightmaps named after the sprite lumps, e.g. VILEA1.png")
+	flagTransRange  = flag.String("translation-range", "", "palette indices the engine recolors at runtime, e.g. 112-127 for players; exported as their own VOX layer and mesh material")
+	flagTransVars   = flag.Bool("translations", false, "also write each model recolored with the game's player translations, e.g. Doom's gray, brown and red; uses -translation-range, the game's player range if unset")
+	flagTruecolor   = flag.Bool("truecolor", false, "keep the exact RGB colors of -color average and median and quantize them only when exporting")
+	flagQuantize    = flag.String("quantize", "doom", "palette -truecolor models are exported with: doom, magicavoxel (its default palette) or optimal (generated per model)")
+	flagDither      = flag.String("dither", "none", "dithering when quantizing -truecolor models: none, ordered or diffuse")
+	flagFrames      = flag.String("frames", "A", "sprite frame letters to voxelize, e.g. ABCD")
+	flagDecorate    = flag.String("decorate", "", "comma-separated DECORATE or ZScript files defining -actor; defaults to the DECORATE and ZSCRIPT lumps of the WADs")
+	flagDeh         = flag.String("deh", "", "comma-separated DEHACKED patches applied to the built-in Doom actors after the DEHACKED lumps of the WADs")
+	flagActor       = flag.String("actor", "", "voxelize all sprite frames used by this actor instead of the built-in sprite list; -animated writes one animation per state")
+	flagStates      = flag.String("states", "", "comma-separated state labels of -actor to use, e.g. Spawn,See; all if empty")
+	flagAnimated    = flag.Bool("animated", false, "also write all frames of each sprite into one anim-NAME.vox with one model per frame")
+)
+
+// carveOverrides holds per-sprite carving thresholds keyed by sprite name
+// (e.g. CYBR) or sprite frame (e.g. CYBRA).
+var carveOverrides = carveFlag{}
+
+// brightColors and brightFrames select the voxels exported as emissive:
+var (
+	brightColors [256]bool
+	brightFrames = map[string]bool{}
+)
+
+// translationRange holds the palette indices the engine recolors at runtime:
+var translationRange [256]bool
+
+func init() {
+	flag.Var(carveOverrides, "carve", "per-sprite carving threshold as NAME=VOTES or NAME=FRACTION, e.g. CYBRA=2 or SPID=0.6; repeatable")
+}
+
+// carveFlag parses repeated NAME=VOTES or NAME=FRACTION values; a value with a
+// decimal point is a fraction.
+type carveFlag map[string]CarveOptions
+
+func (f carveFlag) String() string {
+	var parts []string
+	for name, opts := range f {
+		if opts.Fraction > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%g", name, opts.Fraction))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%d", name, opts.Votes))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (f carveFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected NAME=VOTES or NAME=FRACTION, got %q", s)
+	}
+
+	opts := DefaultCarveOptions()
+	if strings.Contains(value, ".") {
+		frac, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if frac <= 0 || frac > 1 {
+			return fmt.Errorf("carve fraction for %s must be in (0, 1], got %g", name, frac)
+		}
+		opts.Fraction = frac
+	} else {
+		votes, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if votes < 1 || votes > 8 {
+			return fmt.Errorf("carve votes for %s must be between 1 and 8, got %d", name, votes)
+		}
+		opts.Votes = votes
+	}
+
+	f[strings.ToUpper(name)] = opts
+	return nil
+}
+
+func parseWeights(s string) (weights []float64, err error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		var w float64
+		w, err = strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return
+		}
+		weights = append(weights, w)
+	}
+	if len(weights) != 8 {
+		err = fmt.Errorf("expected 8 rotation weights, got %d", len(weights))
+	}
+	return
+}
+
+// loadPalette returns palette n of the PLAYPAL lump.
+func loadPalette(wc *WADCollection, n int) (color.Palette, error) {
+	palLump := wc.FindLumpBetween("", "", func(s string) bool {
+		return s == "PLAYPAL"
+	})
+	if palLump == nil {
+		return nil, fmt.Errorf("could not find PLAYPAL")
+	}
+	playpal, err := palette.ParsePLAYPAL(palLump.Data)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= len(playpal) {
+		return nil, fmt.Errorf("-playpal %d out of range; PLAYPAL has %d palettes", n, len(playpal))
+	}
+	return playpal[n], nil
+}
+
+// exitOnCommandError ends the program after a command failed: quietly for
+// -help, with status 2 and the error on stderr otherwise.
+func exitOnCommandError(err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
+
 func main() {
 	var err error
 
-	iwadPath := os.ExpandEnv("$DOOMWADDIR/DOOM2.WAD")
+	flag.Parse()
+
+	iwadPath := *flagIWAD
+	if iwadPath == "" {
+		iwadPath, err = findIWAD(os.ExpandEnv("$DOOMWADDIR"))
+		if err != nil {
+			panic(err)
+		}
+	}
 	err = wc.Load(iwadPath)
 	if err != nil {
 		panic(err)
 	}
-
-	pwadPath := os.ExpandEnv("$DOOMWADDIR/D2SPFX20.WAD")
-	err = wc.Load(pwadPath)
+	game, err = identifyGame(wc.Ordered[0])
 	if err != nil {
 		panic(err)
 	}
 
-	for _, wad := range wc.Ordered {
-		for _, lump := range wad.Lumps {
-			fmt.Printf("%-12s\t%-8s\t%x\n", wad.Name, lump.Name, len(lump.Data))
+	var pwadPaths []string
+	if *flagFiles != "" {
+		for _, path := range strings.Split(*flagFiles, ",") {
+			pwadPaths = append(pwadPaths, strings.TrimSpace(path))
+		}
+	} else {
+		for _, name := range game.PWADs {
+			if path := findFile(filepath.Dir(iwadPath), name); path != "" {
+				pwadPaths = append(pwadPaths, path)
+			}
+		}
+	}
+	for _, pwadPath := range pwadPaths {
+		err = wc.Load(pwadPath)
+		if err != nil {
+			panic(err)
 		}
 	}
 
-	// find palette:
-	palLump := wc.FindLumpBetween("", "", func(s string) bool {
-		if s == "PLAYPAL" {
-			return true
+	// commands that only inspect the WADs skip the voxelizer's options:
+	switch flag.Arg(0) {
+	case "":
+	case "list":
+		exitOnCommandError(runList(&wc, flag.Args()[1:], os.Stdout))
+		return
+	case "extract":
+		pal, err := loadPalette(&wc, *flagPlaypal)
+		if err == nil {
+			err = runExtract(&wc, pal, flag.Args()[1:])
 		}
-		return false
-	})
-	if palLump == nil {
-		panic("could not find PLAYPAL")
-	}
-	// load palette:
-	pal := make(color.Palette, 0, 256)
-	for i := 0; i < 256; i++ {
-		pal = append(pal, color.RGBA{
-			R: palLump.Data[i*3+0],
-			G: palLump.Data[i*3+1],
-			B: palLump.Data[i*3+2],
-			A: 255,
+		exitOnCommandError(err)
+		return
+	default:
+		exitOnCommandError(fmt.Errorf("unknown command %q; commands are list and extract", flag.Arg(0)))
+	}
+
+	carveDefaults := DefaultCarveOptions()
+	if *flagCarveVotes < 1 || *flagCarveVotes > 8 {
+		panic(fmt.Errorf("-carve-votes must be between 1 and 8, got %d", *flagCarveVotes))
+	}
+	carveDefaults.Votes = *flagCarveVotes
+	carveDefaults.Fraction = *flagCarveFrac
+	carveDefaults.Weights, err = parseWeights(*flagCarveWeight)
+	if err != nil {
+		panic(err)
+	}
+	for name, opts := range carveOverrides {
+		opts.Weights = carveDefaults.Weights
+		carveOverrides[name] = opts
+	}
+
+	colorStrategy, err := ParseColorStrategy(*flagColor)
+	if err != nil {
+		panic(err)
+	}
+
+	if *flagTruecolor {
+		if colorStrategy != ColorAverage && colorStrategy != ColorMedian {
+			panic(fmt.Errorf("-truecolor keeps the colors of -color average or median, not %s", *flagColor))
+		}
+		switch *flagQuantize {
+		case "doom", "magicavoxel":
+		case "optimal":
+			if *flagAnimated || *flagMD3 {
+				panic(fmt.Errorf("-quantize optimal makes a palette per model, which frames of an animation cannot share; use doom or magicavoxel"))
+			}
+		default:
+			panic(fmt.Errorf("-quantize must be doom, magicavoxel or optimal, got %q", *flagQuantize))
+		}
+		if *flagQuantize != "doom" && *flagLight >= 0 {
+			panic(fmt.Errorf("-light bakes the Doom palette and needs -quantize doom"))
+		}
+		if *flagQuantize != "doom" && *flagSpriteWAD != "" {
+			panic(fmt.Errorf("-sprite-wad renders sprites in the Doom palette and needs -quantize doom"))
+		}
+	}
+
+	interiorOpts := DefaultInteriorOptions()
+	interiorOpts.Shell = *flagShell
+	interiorOpts.Hollow = *flagHollow
+	interiorOpts.FillColor = *flagFillColor
+	if interiorOpts.FillColor > 255 {
+		panic(fmt.Errorf("-fill-color must be a palette index 0-255, got %d", interiorOpts.FillColor))
+	}
+	if interiorOpts.Hollow && interiorOpts.Shell <= 0 {
+		panic(fmt.Errorf("-hollow removes the voxels deeper than -shell, which must be at least 1"))
+	}
+	if interiorOpts.Hollow && interiorOpts.FillColor >= 0 {
+		panic(fmt.Errorf("-hollow and -fill-color both act on the interior; use one"))
+	}
+
+	cleanupOpts := DefaultCleanupOptions()
+	cleanupOpts.Connectivity = *flagConnect
+	cleanupOpts.MinComponent = *flagMinComp
+	cleanupOpts.Open = *flagOpen
+	cleanupOpts.Close = *flagClose
+	cleanupOpts.Kernel = *flagKernel
+	if cleanupOpts.Connectivity != 6 && cleanupOpts.Connectivity != 26 {
+		panic(fmt.Errorf("-connectivity must be 6 or 26, got %d", cleanupOpts.Connectivity))
+	}
+	if cleanupOpts.Kernel != "cube" && cleanupOpts.Kernel != "sphere" {
+		panic(fmt.Errorf("-kernel must be cube or sphere, got %q", cleanupOpts.Kernel))
+	}
+
+	symmetryOpts := DefaultSymmetryOptions()
+	symmetryOpts.Mode = *flagSymmetry
+	symmetryOpts.Search = *flagSymSearch
+	symmetryOpts.Merge = *flagSymMerge
+	symmetryOpts.Color = *flagSymColor
+
+	translationTables, err = game.loadTranslations(&wc)
+	if err != nil {
+		panic(err)
+	}
+
+	pal, err := loadPalette(&wc, *flagPlaypal)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%s: %s\n", filepath.Base(iwadPath), game.Name)
+
+	// exporters get the palette as seen at the chosen light level; carving
+	// and coloring keep working with the -playpal palette:
+	outPal := pal
+	var colormap palette.COLORMAP
+	if *flagLight >= 0 || *flagBrightCM {
+		cmLump := wc.FindLumpBetween("", "", func(s string) bool {
+			return s == "COLORMAP"
 		})
+		if cmLump == nil {
+			panic("could not find COLORMAP")
+		}
+		colormap, err = palette.ParseCOLORMAP(cmLump.Data)
+		if err != nil {
+			panic(err)
+		}
 	}
-	{
-		col := pal[0xFF].(color.RGBA)
-		col.A = 0
+	brightColors, err = parseColorRanges(*flagBrightCols)
+	if err != nil {
+		panic(err)
+	}
+	if *flagBrightCM {
+		for i, b := range colormap.Fullbright() {
+			// black stays black however bright it is:
+			if b && i != 0 {
+				brightColors[i] = true
+			}
+		}
+	}
+	for _, name := range strings.Split(*flagBrightFrms, ",") {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			brightFrames[name] = true
+		}
+	}
+	// glowing colors and frames keep their full brightness, as in the
+	// engine:
+	litPal := outPal
+	if *flagLight >= 0 {
+		if outPal, err = colormap.Bake(pal, *flagLight, brightColors); err != nil {
+			panic(err)
+		}
+		if litPal, err = colormap.Bake(pal, 0, brightColors); err != nil {
+			panic(err)
+		}
+	}
+
+	if *flagTransRange == "" && *flagTransVars {
+		if game.PlayerRange == "" {
+			panic(fmt.Errorf("-translations: %s has no player translations; set -translation-range", game.Name))
+		}
+		*flagTransRange = game.PlayerRange
+	}
+	translationRange, err = parseColorRanges(*flagTransRange)
+	if err != nil {
+		panic(err)
+	}
+	if *flagVoxIn != "" {
+		var vol *Volume
+		vol, err = loadVoxel(*flagVoxIn, pal, *flagVoxFrame)
+		if err != nil {
+			panic(err)
+		}
+
+		name := strings.ToUpper(*flagVoxName)
+		if name == "" {
+			name = strings.ToUpper(strings.TrimSuffix(filepath.Base(*flagVoxIn), filepath.Ext(*flagVoxIn)))
+			name = strings.TrimPrefix(name, "MDL-")
+		}
+		if len(name) != 5 {
+			panic(fmt.Errorf("%q is not a sprite name and frame like CYBRA; use -vox-name", name))
+		}
+
+		markEmissive(vol, name[:4], name[4])
+		markTranslation(vol)
+		spriteLumps := exportModel(name[:4], name[4], vol, quantizeModel(vol, pal, outPal))
+		if *flagSpriteWAD != "" {
+			saveSpriteWAD(*flagSpriteWAD, spriteLumps)
+		}
+		return
 	}
 
 	// Calculate camera angles and directions
@@ -110,12 +471,6 @@ func main() {
 		0,
 	}
 
-	//baseNames := []string{"CYBR", "SPID", "VILE", "POSS", "SPOS", "CPOS", "TROO", "SARG"}
-	baseNames := []string{"CYBR", "VILE", "POSS", "SPOS", "CPOS", "TROO", "SARG"}
-	//baseNames := []string{"CYBR", "SPID"}
-	//baseNames := []string{"CYBR"}
-	//baseNames := []string{"SPID"}
-
 	// left, top:
 	postAdj := make(map[string]*[8][2]int)
 
@@ -149,49 +504,98 @@ func main() {
 	postAdj["SPOSA"][6] = [2]int{0, 0}
 	postAdj["SPOSA"][7] = [2]int{0, 0}
 
-	for _, baseName := range baseNames {
-		for frame := 0; frame < 1; frame++ {
-			frameCh := uint8('A' + frame)
+	// the sprite frames to voxelize, from -actor or the list above:
+	var jobs []decorate.SpriteFrames
+	var actor *decorate.Actor
+	var labels []string
+	if *flagActor != "" {
+		var actors []*decorate.Actor
+		actors, err = loadActors(&wc, game.DoomActors, *flagDecorate, *flagDeh)
+		if err != nil {
+			panic(err)
+		}
+		actor, err = decorate.Find(actors, *flagActor)
+		if err != nil {
+			panic(err)
+		}
+		for _, label := range strings.Split(*flagStates, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, label)
+			}
+		}
+		jobs = actor.Frames(labels...)
+		if len(jobs) == 0 {
+			panic(fmt.Errorf("actor %s shows no sprites in the chosen states", actor.Name))
+		}
+		// frames the actor draws fullbright glow as with -bright-frames:
+		for _, s := range actor.States {
+			if s.Bright {
+				brightFrames[fmt.Sprintf("%s%c", s.Sprite, s.Frame)] = true
+			}
+		}
+	} else {
+		for _, baseName := range game.Sprites {
+			jobs = append(jobs, decorate.SpriteFrames{Sprite: baseName, Frames: strings.ToUpper(*flagFrames)})
+		}
+	}
+
+	// -actor animations are put together per state once all frames are done:
+	stateFrames := map[string]voxFrame{}
+	stateMeshes := map[string]meshFrame{}
+
+	var spriteLumps []Lump
+	animPal := outPal
+
+	for _, job := range jobs {
+		baseName := job.Sprite
+		var animFrames []voxFrame
+		var animMeshes []meshFrame
+		for f := 0; f < len(job.Frames); f++ {
+			frameCh := job.Frames[f]
 			baseFrameLumpName := fmt.Sprintf("%s%c", baseName, frameCh)
 
-			// find all 8 sprite rotations:
+			// find all 8 sprite rotations; as in the engine, the last lump of
+			// a rotation within a WAD wins, and WADs loaded later override
+			// earlier ones:
 			lumps := [8]*Lump{}
-			lumpsFound := 0
-			wc.IterateLumpsBetween("S_START", "S_END", func(lump *Lump) bool {
-				s := lump.Name
-				if s[:4] != baseName {
-					return false
-				}
-
-				if len(s) >= 6 {
-					if s[4] == frameCh {
-						r := s[5] - '0'
-						if r >= 1 && r <= 8 {
-							if lumps[r-1] == nil {
-								lump.HFlip = false
-								lumps[r-1] = lump
-								lumpsFound++
-							}
-						}
+			for _, wad := range wc.Ordered {
+				found := [8]*Lump{}
+				wad.IterateSprites(func(lump *Lump) bool {
+					s := lump.Name
+					if len(s) < 6 || s[:4] != baseName {
+						return false
 					}
-				}
-
-				if len(s) == 8 {
-					if s[6] == frameCh {
-						r := s[7] - '0'
-						if r >= 1 && r <= 8 {
-							if lumps[r-1] == nil {
-								lump.HFlip = true
-								lumps[r-1] = lump
-								lumpsFound++
-							}
+					// each rotation gets its own copy, as the second one of
+					// a lump like TROOA2A8 is flipped:
+					add := func(frame, rotation byte, flip bool) {
+						if r := rotation - '0'; frame == frameCh && r >= 1 && r <= 8 {
+							l := *lump
+							l.HFlip = flip
+							found[r-1] = &l
 						}
 					}
+					add(s[4], s[5], false)
+					if len(s) == 8 {
+						add(s[6], s[7], true)
+					}
+					return false
+				})
+				for r := range lumps {
+					if lumps[r] == nil {
+						lumps[r] = found[r]
+					}
 				}
-
-				// break when 8 found:
-				return lumpsFound == 8
-			})
+			}
+			lumpsFound := 0
+			for _, lump := range lumps {
+				if lump != nil {
+					lumpsFound++
+				}
+			}
+			if lumpsFound < 8 {
+				fmt.Printf("%s: found %d of 8 rotations; skipping\n", baseFrameLumpName, lumpsFound)
+				continue
+			}
 
 			xmin := 384
 			ymin := 256
@@ -199,6 +603,8 @@ func main() {
 			ymax := 0
 
 			rotations := [8]*image.Paletted{}
+			masks := [8]*image.Alpha{}
+			brights := [8]*image.Alpha{}
 			for p, lump := range lumps {
 				spr := lump.Data
 				size := uint32(len(spr))
@@ -211,11 +617,20 @@ func main() {
 
 				rect := image.Rect(0, 0, 300, 192)
 				img := image.NewPaletted(rect, pal)
-				for i := range img.Pix {
-					img.Pix[i] = 0xFF
+				mask := image.NewAlpha(rect)
+
+				var brightmap image.Image
+				if *flagBrightmaps != "" {
+					brightmap, err = loadBrightmap(*flagBrightmaps, lump.Name)
+					if err != nil {
+						panic(err)
+					}
+					if brightmap != nil {
+						brights[p] = image.NewAlpha(rect)
+					}
 				}
 
-				if adj, ok := postAdj[baseFrameLumpName]; ok && adj != nil {
+				if adj, ok := postAdj[baseFrameLumpName]; ok && adj != nil && game.OffsetFixes {
 					leftoffs += adj[p][0]
 					topoffs += adj[p][1]
 				}
@@ -258,6 +673,10 @@ func main() {
 									ymax = y
 								}
 								img.SetColorIndex(x, y, run[j])
+								mask.SetAlpha(x, y, color.Alpha{A: 0xFF})
+								if isBright(brightmap, i, ystart+j-3) {
+									brights[p].SetAlpha(x, y, color.Alpha{A: 0xFF})
+								}
 							}
 						} else {
 							x := xadj + i
@@ -276,6 +695,10 @@ func main() {
 									ymax = y
 								}
 								img.SetColorIndex(x, y, run[j])
+								mask.SetAlpha(x, y, color.Alpha{A: 0xFF})
+								if isBright(brightmap, i, ystart+j-3) {
+									brights[p].SetAlpha(x, y, color.Alpha{A: 0xFF})
+								}
 							}
 						}
 						runOffs += uint32(length)
@@ -284,18 +707,15 @@ func main() {
 				}
 
 				rotations[p] = img
-
-				func() {
-					f, err := os.Create(fmt.Sprintf("fr-%s%c%d.png", baseName, frameCh, p+1))
-					if err != nil {
-						panic(err)
-					}
-					defer f.Close()
-					err = png.Encode(f, img)
-					if err != nil {
-						panic(err)
-					}
-				}()
+				masks[p] = mask
+
+				// show the transparent pixels as such:
+				out := image.NewNRGBA(rect)
+				draw.DrawMask(out, rect, img, image.Point{}, mask, image.Point{}, draw.Src)
+				err = savePNG(fmt.Sprintf("fr-%s%c%d.png", baseName, frameCh, p+1), out)
+				if err != nil {
+					panic(err)
+				}
 			}
 
 			fmt.Printf("%d, %d, %d, %d\n", xmin, ymin, xmax, ymax)
@@ -303,6 +723,15 @@ func main() {
 				// extract minimal image:
 				rotations[p] = image.NewPaletted(image.Rect(0, 0, xmax-xmin, ymax-ymin), img.Palette)
 				draw.Draw(rotations[p], rotations[p].Rect, img, image.Point{X: xmin, Y: ymin}, draw.Over)
+
+				mask := masks[p]
+				masks[p] = image.NewAlpha(rotations[p].Rect)
+				draw.Draw(masks[p], masks[p].Rect, mask, image.Point{X: xmin, Y: ymin}, draw.Src)
+
+				if bright := brights[p]; bright != nil {
+					brights[p] = image.NewAlpha(rotations[p].Rect)
+					draw.Draw(brights[p], brights[p].Rect, bright, image.Point{X: xmin, Y: ymin}, draw.Src)
+				}
 			}
 
 			maxwidth := xmax - xmin
@@ -316,21 +745,9 @@ func main() {
 			// X - (width)
 			// Y / (depth)
 			// Z | (height)
-			voxels := make([][][]uint8, maxx)
-			for i := 0; i < maxx; i++ {
-				voxels[i] = make([][]uint8, maxy)
-				for j := 0; j < maxy; j++ {
-					voxels[i][j] = make([]uint8, maxz)
-				}
-			}
-
-			volume := make([][][]bool, maxx)
-			for i := 0; i < maxx; i++ {
-				volume[i] = make([][]bool, maxy)
-				for j := 0; j < maxy; j++ {
-					volume[i][j] = make([]bool, maxz)
-				}
-			}
+			vol := NewVolume(maxx, maxy, maxz)
+			voxels := vol.Voxels
+			volume := vol.Filled
 
 			horizCenter := float64(maxwidth) / 2.0
 			vertCenter := float64(maxheight) / 2.0
@@ -339,9 +756,15 @@ func main() {
 			yCenter := float64(maxy) / 2.0
 			zCenter := float64(maxz) / 2.0
 
-			const step = 4
-			radius := float64(maxwidth) * 2
-			halfRadius := radius / 2.0
+			// the sprites turn around the grid center; ground level is the
+			// last row above the 16px floor margin of the canvas:
+			vol.Pivot = vector3.V{
+				X: xCenter + 0.5,
+				Y: yCenter + 0.5,
+				Z: math.Round(float64(maxheight-1-(192-16-1-ymin)) - vertCenter + zCenter),
+			}
+
+			halfRadius := float64(maxwidth)
 
 			{
 				// projection and rotation test:
@@ -352,7 +775,7 @@ func main() {
 					for u := 0; u < maxwidth; u++ {
 						for v := 0; v < maxheight; v++ {
 							c := img.ColorIndexAt(u, maxheight-1-v)
-							if c != 0xFF {
+							if masks[i].AlphaAt(u, maxheight-1-v).A != 0 {
 								p := vector3.V{
 									X: float64(u) - horizCenter,
 									Y: -halfRadius,
@@ -385,355 +808,407 @@ func main() {
 					os.ExpandEnv(
 						fmt.Sprintf("$HOME/Downloads/MagicaVoxel-0.99.6.2-macos-10.15/vox/prj-%s%c.vox", baseName, frameCh),
 					),
-					uint32(maxx),
-					uint32(maxy),
-					uint32(maxz),
+					vol,
 					pal,
-					voxels,
-					volume,
 				)
+				if err != nil {
+					panic(err)
+				}
 				fmt.Printf("prj-%s%c.vox: saved\n", baseName, frameCh)
 
 				// reset:
-				for x := 0; x < maxx; x++ {
-					for y := 0; y < maxy; y++ {
-						for z := 0; z < maxz; z++ {
-							volume[x][y][z] = false
-							voxels[x][y][z] = 0
-						}
-					}
-				}
+				vol.Clear()
 			}
 
 			{
-				if true {
-					fmt.Printf("mdl-%s%c.vox: voxelize step 1/3\n", baseName, frameCh)
-					for i := range reorder {
-						img := rotations[reorder[i]]
-
-						for u := 0; u < maxwidth; u++ {
-							for v := 0; v < maxheight; v++ {
-								c := img.ColorIndexAt(u, maxheight-1-v)
-								if c != 0xFF {
-									for t := 0.0; t < radius*step; t++ {
-										p := vector3.V{
-											X: float64(u) - horizCenter,
-											Y: float64(t)*(1.0/step) - halfRadius,
-											Z: float64(v) - vertCenter,
-										}
-										p = cameraTransforms[reorder[i]].Transform(p)
-
-										x := int(math.Round(p.X + xCenter))
-										if x < 0 || x >= maxx {
-											continue
-										}
-										y := int(math.Round(p.Y + yCenter))
-										if y < 0 || y >= maxy {
-											continue
-										}
-										z := int(math.Round(p.Z + zCenter))
-										if z < 0 || z >= maxz {
-											continue
-										}
-
-										volume[x][y][z] = true
-										voxels[x][y][z] = c
-									}
-								}
-							}
-						}
-					}
-				} else {
-					for x := 0; x < maxwidth; x++ {
-						for y := 0; y < maxwidth; y++ {
-							for z := 0; z < maxheight; z++ {
-								volume[x][y][z] = true
-							}
-						}
-					}
+				vz := NewVoxelizer(vol, rotations, masks, cameraTransforms, reorder, maxwidth, maxheight)
+				vz.Truecolor = *flagTruecolor
+
+				fmt.Printf("mdl-%s%c.vox: voxelize step 1/3\n", baseName, frameCh)
+				vz.Fill()
+
+				carve := carveDefaults
+				if opts, ok := carveOverrides[baseFrameLumpName]; ok {
+					carve = opts
+				} else if opts, ok := carveOverrides[baseName]; ok {
+					carve = opts
 				}
 
-				if true {
-					fmt.Printf("mdl-%s%c.vox: voxelize step 2/3\n", baseName, frameCh)
-					for i := range reorder {
-						img := rotations[reorder[i]]
-
-						for u := 0; u < maxwidth; u++ {
-							for v := 0; v < maxheight; v++ {
-								c := img.ColorIndexAt(u, maxheight-1-v)
-								if c == 0xFF {
-									for t := 0.0; t < radius*step; t++ {
-										p := vector3.V{
-											X: float64(u) - horizCenter,
-											Y: float64(t)*(1.0/step) - halfRadius,
-											Z: float64(v) - vertCenter,
-										}
-										p = cameraTransforms[reorder[i]].Transform(p)
-
-										x := int(math.Round(p.X + xCenter))
-										if x < 0 || x >= maxx {
-											continue
-										}
-										y := int(math.Round(p.Y + yCenter))
-										if y < 0 || y >= maxy {
-											continue
-										}
-										z := int(math.Round(p.Z + zCenter))
-										if z < 0 || z >= maxz {
-											continue
-										}
-
-										volume[x][y][z] = false
-									}
-								}
-							}
-						}
+				fmt.Printf("mdl-%s%c.vox: voxelize step 2/3\n", baseName, frameCh)
+				vz.Carve(carve)
 
-						// wipe out anything outside the image bounds:
-						for u := maxwidth; u < int(radius); u++ {
-							for v := 0; v < maxheight; v++ {
-								for t := 0.0; t < radius*step; t++ {
-									p := vector3.V{
-										X: float64(u) - horizCenter,
-										Y: float64(t)*(1.0/step) - halfRadius,
-										Z: float64(v) - vertCenter,
-									}
-									p = cameraTransforms[reorder[i]].Transform(p)
-
-									x := int(math.Round(p.X + xCenter))
-									if x < 0 || x >= maxx {
-										continue
-									}
-									y := int(math.Round(p.Y + yCenter))
-									if y < 0 || y >= maxy {
-										continue
-									}
-									z := int(math.Round(p.Z + zCenter))
-									if z < 0 || z >= maxz {
-										continue
-									}
-
-									volume[x][y][z] = false
-								}
+				var cleanup CleanupReport
+				cleanup, err = vol.Cleanup(cleanupOpts)
+				if err != nil {
+					panic(err)
+				}
+				if cleanup.RemovedComponents > 0 {
+					fmt.Printf("mdl-%s%c.vox: removed %d of %d components (%d voxels)\n", baseName, frameCh, cleanup.RemovedComponents, cleanup.Components, cleanup.RemovedComponentVoxels)
+				}
+				if cleanup.RemovedByOpen > 0 {
+					fmt.Printf("mdl-%s%c.vox: opening removed %d voxels\n", baseName, frameCh, cleanup.RemovedByOpen)
+				}
+				if cleanup.AddedByClose > 0 {
+					fmt.Printf("mdl-%s%c.vox: closing added %d voxels\n", baseName, frameCh, cleanup.AddedByClose)
+				}
 
-								for t := 0.0; t < radius*step; t++ {
-									p := vector3.V{
-										X: float64(maxwidth-u) - horizCenter,
-										Y: float64(t)*(1.0/step) - halfRadius,
-										Z: float64(v) - vertCenter,
-									}
-									p = cameraTransforms[reorder[i]].Transform(p)
-
-									x := int(math.Round(p.X + xCenter))
-									if x < 0 || x >= maxx {
-										continue
-									}
-									y := int(math.Round(p.Y + yCenter))
-									if y < 0 || y >= maxy {
-										continue
-									}
-									z := int(math.Round(p.Z + zCenter))
-									if z < 0 || z >= maxz {
-										continue
-									}
-
-									volume[x][y][z] = false
-								}
-							}
-						}
+				// recolor the surfaces from each angle:
+				fmt.Printf("mdl-%s%c.vox: voxelize step 3/3\n", baseName, frameCh)
+				vz.Color(colorStrategy)
+
+				if symmetryOpts.Mode != "off" {
+					var sym SymmetryReport
+					sym, err = vol.Symmetrize(pal, symmetryOpts)
+					if err != nil {
+						panic(err)
 					}
+					fmt.Printf("mdl-%s%c.vox: symmetry plane x=%.1f (score %.3f): %d added, %d removed, %d recolored\n",
+						baseName, frameCh, sym.Plane, sym.Score, sym.Added, sym.Removed, sym.Recolored)
 				}
 
-				// recolor the surfaces from each angle:
-				if true {
-					fmt.Printf("mdl-%s%c.vox: voxelize step 3/3\n", baseName, frameCh)
-					for i := range reorder {
-						img := rotations[reorder[i]]
-						for u := 0; u < maxwidth; u++ {
-							for v := 0; v < maxheight; v++ {
-								c := img.ColorIndexAt(u, maxheight-1-v)
-								if c != 0xFF {
-									depth := 0
-									for t := 0.0; t < radius*step; t++ {
-										p := vector3.V{
-											X: float64(u) - horizCenter,
-											Y: float64(t)*(1.0/step) - halfRadius,
-											Z: float64(v) - vertCenter,
-										}
-										p = cameraTransforms[reorder[i]].Transform(p)
-
-										x := int(math.Round(p.X + xCenter))
-										if x < 0 || x >= maxx {
-											continue
-										}
-										y := int(math.Round(p.Y + yCenter))
-										if y < 0 || y >= maxy {
-											continue
-										}
-										z := int(math.Round(p.Z + zCenter))
-										if z < 0 || z >= maxz {
-											continue
-										}
-
-										voxels[x][y][z] = c
-
-										// only color the surface:
-										if volume[x][y][z] {
-											depth++
-											if depth > 3 {
-												break
-											}
-										}
-									}
-								}
-							}
-						}
+				report := vol.ProcessInterior(interiorOpts)
+				for _, cavity := range report.Cavities {
+					fmt.Printf("mdl-%s%c.vox: enclosed cavity of %d voxels at %v-%v\n", baseName, frameCh, cavity.Size, cavity.Min, cavity.Max)
+				}
+				if report.Hollowed > 0 {
+					fmt.Printf("mdl-%s%c.vox: hollowed %d interior voxels\n", baseName, frameCh, report.Hollowed)
+				}
+				if report.Filled > 0 {
+					fmt.Printf("mdl-%s%c.vox: filled %d interior voxels with color %d\n", baseName, frameCh, report.Filled, interiorOpts.FillColor)
+				}
+				vz.MarkBright(brights)
+				markEmissive(vol, baseName, frameCh)
+				markTranslation(vol)
+				animPal = quantizeModel(vol, pal, outPal)
+				framePal := animPal
+				if *flagLight >= 0 && isBrightFrame(baseName, frameCh) {
+					framePal = litPal
+				}
+				spriteLumps = append(spriteLumps, exportModel(baseName, frameCh, vol, framePal)...)
+				if *flagAnimated && actor != nil {
+					stateFrames[baseFrameLumpName] = newVoxFrame(vol)
+				} else if *flagAnimated {
+					animFrames = append(animFrames, newVoxFrame(vol))
+				}
+				if *flagMD3 || (*flagAnimated && *flagGLB) {
+					animMeshes = append(animMeshes, meshFrame{
+						Name:   baseFrameLumpName,
+						Quads:  vol.GreedyMesh(),
+						Origin: voxOrigin(vol),
+					})
+					if *flagAnimated && *flagGLB && actor != nil {
+						stateMeshes[baseFrameLumpName] = animMeshes[len(animMeshes)-1]
 					}
 				}
+			}
+		}
 
-				fmt.Printf("mdl-%s%c.vox: saving...\n", baseName, frameCh)
-				err = saveVoxel(
-					os.ExpandEnv(
-						fmt.Sprintf("$HOME/Downloads/MagicaVoxel-0.99.6.2-macos-10.15/vox/mdl-%s%c.vox", baseName, frameCh),
-					),
-					uint32(maxx),
-					uint32(maxy),
-					uint32(maxz),
-					pal,
-					voxels,
-					volume,
-				)
-				fmt.Printf("mdl-%s%c.vox: saved\n", baseName, frameCh)
+		if *flagAnimated && len(animFrames) > 0 {
+			var meshes []meshFrame
+			if *flagGLB {
+				meshes = animMeshes
+			}
+			saveAnimation(baseName, animFrames, meshes, animPal)
+		}
+		if *flagMD3 && len(animMeshes) > 0 {
+			class := baseName
+			if actor != nil {
+				class = actor.Name
 			}
+			saveModel(class, baseName, animMeshes, animPal)
 		}
 	}
+
+	if *flagAnimated && actor != nil {
+		saveStateAnimations(actor, labels, stateFrames, stateMeshes, animPal)
+	}
+
+	if *flagSpriteWAD != "" {
+		saveSpriteWAD(*flagSpriteWAD, spriteLumps)
+	}
 }
 
-func saveVoxel(voxPath string, maxx, maxy, maxz uint32, pal color.Palette, voxels [][][]byte, volume [][][]bool) (err error) {
-	// Create VOX output file
-	file := &bytes.Buffer{}
+func saveSpriteWAD(path string, spriteLumps []Lump) {
+	lumps := make([]Lump, 0, len(spriteLumps)+2)
+	lumps = append(lumps, Lump{Name: "S_START"})
+	lumps = append(lumps, spriteLumps...)
+	lumps = append(lumps, Lump{Name: "S_END"})
 
-	// Write VOX header with version
-	if _, err = file.Write([]byte("VOX ")); err != nil {
-		return
+	err := WriteWAD(path, "PWAD", lumps)
+	if err != nil {
+		panic(err)
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(150)); err != nil {
+	fmt.Printf("%s: saved %d sprites\n", path, len(spriteLumps))
+}
+
+// markEmissive makes the voxels of fullbright frames and colors glow.
+func markEmissive(vol *Volume, baseName string, frameCh byte) {
+	if isBrightFrame(baseName, frameCh) {
+		var all [256]bool
+		for i := range all {
+			all[i] = true
+		}
+		vol.MarkEmissiveColors(all)
 		return
 	}
+	vol.MarkEmissiveColors(brightColors)
+}
+
+// isBrightFrame tells whether the sprite frame is drawn fullbright.
+func isBrightFrame(baseName string, frameCh byte) bool {
+	return brightFrames[baseName] || brightFrames[fmt.Sprintf("%s%c", baseName, frameCh)]
+}
+
+// quantizeModel quantizes the truecolor voxels of a -truecolor model to the
+// -quantize palette and returns the palette to export the model with.
+// Otherwise the model already uses the Doom palette, exported as outPal.
+func quantizeModel(vol *Volume, pal, outPal color.Palette) color.Palette {
+	if !*flagTruecolor {
+		return outPal
+	}
 
-	// Write MAIN chunk to describe the size of the file
-	if _, err = file.Write([]byte("MAIN")); err != nil {
-		return
+	target := pal
+	switch *flagQuantize {
+	case "magicavoxel":
+		// index i is written to VOX color index i+1:
+		target = make(color.Palette, 255)
+		for i := range target {
+			target[i] = magicaVoxelDefaultPalette[i+1]
+		}
+	case "optimal":
+		// VOX files hold 255 colors:
+		target = palette.MedianCut(vol.voxelColors(pal), 255)
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0)); err != nil {
-		return
+	if err := vol.Quantize(pal, target, *flagDither); err != nil {
+		panic(err)
+	}
+	if *flagQuantize == "doom" {
+		return outPal
 	}
 
-	var mainLenOffs = file.Len()
-	if err = binary.Write(file, binary.LittleEndian, uint32(0)); err != nil {
-		return
+	// the translation range holds Doom palette indices, which mean nothing
+	// in other palettes; glowing voxels are marked one by one and stay:
+	vol.Translation = [256]bool{}
+	exportPal := make(color.Palette, 256)
+	copy(exportPal, target)
+	for i := len(target); i < len(exportPal); i++ {
+		exportPal[i] = color.RGBA{A: 0xFF}
 	}
+	return exportPal
+}
 
-	// Write SIZE chunk to describe the voxel dimensions
-	if _, err = file.Write([]byte("SIZE")); err != nil {
-		return
+// markTranslation adds the -translation-range colors to those the volume
+// already keeps apart, e.g. from the layers of a loaded VOX file.
+func markTranslation(vol *Volume) {
+	for i, t := range translationRange {
+		if t {
+			vol.Translation[i] = true
+		}
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0xC)); err != nil {
+}
+
+// saveTranslations writes the model recolored with each of Doom's player
+// translations, as mdl-NAME-gray.vox and so on, with meshes if enabled.
+func saveTranslations(name string, vol *Volume, pal color.Palette) {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
 		return
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0)); err != nil {
-		return
+	translated := false
+	for x := lo[0]; x <= hi[0] && !translated; x++ {
+		for y := lo[1]; y <= hi[1] && !translated; y++ {
+			for z := lo[2]; z <= hi[2] && !translated; z++ {
+				translated = vol.IsTranslatable(x, y, z)
+			}
+		}
 	}
-	// x
-	if err = binary.Write(file, binary.LittleEndian, maxx); err != nil {
+	if !translated {
+		fmt.Printf("mdl-%s.vox: no voxels in the translation range\n", name)
 		return
 	}
-	// y
-	if err = binary.Write(file, binary.LittleEndian, maxy); err != nil {
-		return
+
+	for _, t := range game.Translations {
+		variant := fmt.Sprintf("%s-%s", name, t.Name)
+		table := palette.NewTranslation(vol.Translation, t.Base)
+		if t.Lump != "" {
+			table = translationTables[t.Lump]
+		}
+		tpal := table.Apply(pal)
+		err := saveVoxel(
+			os.ExpandEnv(
+				fmt.Sprintf("$HOME/Downloads/MagicaVoxel-0.99.6.2-macos-10.15/vox/mdl-%s.vox", variant),
+			),
+			vol,
+			tpal,
+		)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("mdl-%s.vox: saved\n", variant)
+
+		if *flagOBJ || *flagPLY || *flagGLB {
+			saveMeshes(variant, vol, tpal)
+		}
 	}
-	// z
-	if err = binary.Write(file, binary.LittleEndian, maxz); err != nil {
-		return
+}
+
+// saveAnimation writes all frames of a sprite into one VOX file.
+func saveAnimation(baseName string, frames []voxFrame, meshes []meshFrame, pal color.Palette) {
+	fmt.Printf("anim-%s.vox: saving %d frames...\n", baseName, len(frames))
+	err := saveVoxelFrames(
+		os.ExpandEnv(
+			fmt.Sprintf("$HOME/Downloads/MagicaVoxel-0.99.6.2-macos-10.15/vox/anim-%s.vox", baseName),
+		),
+		frames,
+		pal,
+	)
+	if err != nil {
+		panic(err)
 	}
+	fmt.Printf("anim-%s.vox: saved\n", baseName)
 
-	// Write XYZI voxel data for indexed-color voxels at X,Y,Z locations
-	if _, err = file.Write([]byte("XYZI")); err != nil {
-		return
+	if len(meshes) > 0 {
+		if err = saveGLB(fmt.Sprintf("anim-%s.glb", baseName), meshes, pal, gltfOptions()); err != nil {
+			panic(err)
+		}
+		fmt.Printf("anim-%s.glb: saved %d frames\n", baseName, len(meshes))
 	}
+}
 
-	var offsSize = file.Len()
+// saveModel writes the frames of a sprite as an MD3 model laid out as in a
+// PK3, along with its skin and a MODELDEF for the actor class; without
+// -actor the sprite name stands in for the class.
+func saveModel(class, baseName string, frames []meshFrame, pal color.Palette) {
+	name := strings.ToLower(baseName)
+	dir := filepath.Join("models", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
 
-	size := uint32(4)
-	count := uint32(0)
-	if err = binary.Write(file, binary.LittleEndian, size); err != nil {
-		return
+	if err := savePNG(filepath.Join(dir, name+".png"), paletteTexture(pal, 16)); err != nil {
+		panic(err)
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0)); err != nil {
-		return
+	if err := saveMD3(filepath.Join(dir, name+".md3"), frames, name+".png"); err != nil {
+		panic(err)
 	}
+	fmt.Printf("%s: saved %d frames\n", filepath.Join(dir, name+".md3"), len(frames))
 
-	var offsCount = file.Len()
-	if err = binary.Write(file, binary.LittleEndian, count); err != nil {
-		return
+	def := modelDef(class, baseName, filepath.ToSlash(dir), name+".md3", name+".png", frames)
+	err := writeFileAtomic(fmt.Sprintf("modeldef.%s.txt", name), func(w io.Writer) error {
+		_, err := io.WriteString(w, def)
+		return err
+	})
+	if err != nil {
+		panic(err)
 	}
+	fmt.Printf("modeldef.%s.txt: saved\n", name)
+}
 
-	for x := uint32(0); x < maxx; x++ {
-		for y := uint32(0); y < maxy; y++ {
-			for z := uint32(0); z < maxz; z++ {
-				if volume[x][y][z] {
-					c := voxels[x][y][z]
-					if _, err = file.Write([]byte{byte(x), byte(y), byte(z), c + 1}); err != nil {
-						return
-					}
-					count++
-					size += 4
-				}
-			}
+// exportModel writes the finished model of a sprite frame to every enabled
+// output and returns its sprite lumps when -sprite-wad is set.
+func exportModel(baseName string, frameCh byte, vol *Volume, pal color.Palette) (spriteLumps []Lump) {
+	fmt.Printf("mdl-%s%c.vox: saving...\n", baseName, frameCh)
+	err := saveVoxel(
+		os.ExpandEnv(
+			fmt.Sprintf("$HOME/Downloads/MagicaVoxel-0.99.6.2-macos-10.15/vox/mdl-%s%c.vox", baseName, frameCh),
+		),
+		vol,
+		pal,
+	)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("mdl-%s%c.vox: saved\n", baseName, frameCh)
+
+	if *flagTransVars {
+		saveTranslations(fmt.Sprintf("%s%c", baseName, frameCh), vol, pal)
+	}
+
+	if *flagQB {
+		name := fmt.Sprintf("mdl-%s%c.qb", baseName, frameCh)
+		if err = saveQB(name, vol, pal, fmt.Sprintf("%s%c", baseName, frameCh)); err != nil {
+			panic(err)
 		}
+		fmt.Printf("%s: saved\n", name)
 	}
 
-	// Write palette
-	if _, err = file.Write([]byte("RGBA")); err != nil {
-		return
+	if *flagKV6 {
+		name := fmt.Sprintf("mdl-%s%c.kv6", baseName, frameCh)
+		if err = saveKV6(name, vol, pal); err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: saved\n", name)
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0x400)); err != nil {
-		return
+
+	if *flagOBJ || *flagPLY || *flagGLB {
+		saveMeshes(fmt.Sprintf("%s%c", baseName, frameCh), vol, pal)
 	}
-	if err = binary.Write(file, binary.LittleEndian, uint32(0)); err != nil {
-		return
+
+	if *flagPreview {
+		renderPreviews(fmt.Sprintf("%s%c", baseName, frameCh), vol, pal)
 	}
-	for _, c := range pal {
-		rgba := c.(color.RGBA)
-		if _, err = file.Write([]byte{
-			rgba.R,
-			rgba.G,
-			rgba.B,
-			rgba.A,
-		}); err != nil {
-			return
+
+	if *flagSpriteWAD != "" {
+		spriteLumps, err = BuildSpriteLumps(baseName, frameCh, vol, pal, *flagRotations)
+		if err != nil {
+			panic(err)
 		}
 	}
 
-	// calculate total file size and rewrite MAIN chunk:
-	var fileSize int
-	fileSize = file.Len()
-	mainSize := uint32(fileSize) - 0x14
+	return
+}
 
-	// capture the buffer:
-	b := file.Bytes()
+func saveMeshes(name string, vol *Volume, pal color.Palette) {
+	quads := vol.GreedyMesh()
+	origin := voxOrigin(vol)
 
-	// patch over the buffer to fill in MAIN size:
-	binary.LittleEndian.PutUint32(b[mainLenOffs:mainLenOffs+4], mainSize)
+	if *flagOBJ {
+		if err := saveOBJ(fmt.Sprintf("mdl-%s.obj", name), quads, origin, pal); err != nil {
+			panic(err)
+		}
+		fmt.Printf("mdl-%s.obj: saved %d faces\n", name, len(quads))
+	}
 
-	// rewrite XYZI header:
-	binary.LittleEndian.PutUint32(b[offsSize:offsSize+4], size)
-	binary.LittleEndian.PutUint32(b[offsCount:offsCount+4], count)
+	if *flagPLY {
+		if err := savePLY(fmt.Sprintf("mdl-%s.ply", name), quads, origin, pal); err != nil {
+			panic(err)
+		}
+		fmt.Printf("mdl-%s.ply: saved %d faces\n", name, len(quads))
+	}
 
-	// write the file:
-	if err = os.WriteFile(voxPath, b, 0644); err != nil {
-		return
+	if *flagGLB {
+		frames := []meshFrame{{Name: name, Quads: quads, Origin: origin}}
+		if err := saveGLB(fmt.Sprintf("mdl-%s.glb", name), frames, pal, gltfOptions()); err != nil {
+			panic(err)
+		}
+		fmt.Printf("mdl-%s.glb: saved %d faces\n", name, len(quads))
 	}
+}
 
-	return
+func gltfOptions() GLTFOptions {
+	opts := DefaultGLTFOptions()
+	opts.Frames = *flagGLBFrames
+	opts.FrameTime = *flagGLBTime
+	return opts
+}
+
+func renderPreviews(name string, vol *Volume, pal color.Palette) {
+	opts := DefaultRenderOptions()
+	opts.Width = *flagPreviewSize
+	opts.Height = *flagPreviewSize
+	opts.Yaw = *flagYaw * math.Pi / 180.0
+	opts.Pitch = *flagPitch * math.Pi / 180.0
+	opts.Perspective = *flagPerspective
+
+	if err := savePNG(fmt.Sprintf("pv-%s.png", name), RenderVolume(vol, pal, opts)); err != nil {
+		panic(err)
+	}
+	fmt.Printf("pv-%s.png: saved\n", name)
+
+	if *flagTurntable > 0 {
+		if err := saveGIF(fmt.Sprintf("tt-%s.gif", name), RenderTurntable(vol, pal, opts, *flagTurntable, 8)); err != nil {
+			panic(err)
+		}
+		fmt.Printf("tt-%s.gif: saved\n", name)
+	}
 }
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// MD3 limits, as in Quake 3:
+const (
+	md3MaxVerts    = 4096
+	md3MaxTris     = 8192
+	md3MaxSurfaces = 32
+	// md3Scale is the number of vertex units per model unit.
+	md3Scale = 64.0
+
+	md3HeaderSize        = 108
+	md3SurfaceHeaderSize = 108
+)
+
+// md3Vertex converts a corner in voxel index space to MD3 coordinates: one
+// unit per voxel relative to the VOX export origin, Z up, with the front of
+// the model facing +X, which is the actor's facing direction in GZDoom.
+func md3Vertex(p, origin [3]int) [3]float64 {
+	x, y, z := float64(p[0]-origin[0]), float64(p[1]-origin[1]), float64(p[2]-origin[2])
+	return [3]float64{-y, x, z}
+}
+
+// md3Normal packs a unit normal the way Quake 3 does: the angle around Z in
+// the high byte and the angle from Z in the low byte.
+func md3Normal(n [3]float64) uint16 {
+	lat := math.Round(math.Atan2(n[1], n[0]) * 255.0 / (2 * math.Pi))
+	lng := math.Round(math.Acos(n[2]) * 255.0 / (2 * math.Pi))
+	return uint16(int(lat)&0xFF)<<8 | uint16(int(lng)&0xFF)
+}
+
+func writeMD3Name(w io.Writer, s string, n int) {
+	name := make([]byte, n)
+	copy(name[:n-1], s)
+	_, _ = w.Write(name)
+}
+
+// md3Face is a quad of one frame in the vertex animation.
+type md3Face struct {
+	Quad   Quad
+	Frame  int
+	Origin [3]int
+}
+
+func saveMD3(path string, frames []meshFrame, skin string) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		return writeMD3(w, frames, skin)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeMD3 writes the frames as one vertex-animated MD3 model textured with
+// the given skin. Every frame needs the same vertices, so the model holds the
+// faces of all frames and each frame collapses the faces of the others onto
+// their centers. Faces are spread over as many surfaces as the MD3 vertex
+// limit requires, up to the limit of 32 surfaces.
+func writeMD3(w io.Writer, frames []meshFrame, skin string) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to save")
+	}
+
+	var faces []md3Face
+	for f, fr := range frames {
+		for _, q := range fr.Quads {
+			faces = append(faces, md3Face{Quad: q, Frame: f, Origin: fr.Origin})
+		}
+	}
+	if len(faces) == 0 {
+		return fmt.Errorf("model is empty")
+	}
+
+	// positions of every face's corners in every frame:
+	pos := func(face md3Face, frame int) (corners [4][3]float64) {
+		for k, p := range face.Quad.Corners {
+			corners[k] = md3Vertex(p, face.Origin)
+		}
+		if frame != face.Frame {
+			var mid [3]float64
+			for _, c := range corners {
+				for a := 0; a < 3; a++ {
+					mid[a] += c[a] / 4
+				}
+			}
+			corners = [4][3]float64{mid, mid, mid, mid}
+		}
+		return
+	}
+
+	perSurface := md3MaxVerts / 4
+	if md3MaxTris/2 < perSurface {
+		perSurface = md3MaxTris / 2
+	}
+	var chunks [][]md3Face
+	for start := 0; start < len(faces); start += perSurface {
+		end := start + perSurface
+		if end > len(faces) {
+			end = len(faces)
+		}
+		chunks = append(chunks, faces[start:end])
+	}
+	if len(chunks) > md3MaxSurfaces {
+		return fmt.Errorf("%d faces over %d frames need %d surfaces; MD3 allows %d", len(faces), len(frames), len(chunks), md3MaxSurfaces)
+	}
+
+	// surface layout: header, shader, triangles, texture coordinates, then
+	// the vertices of every frame:
+	surfaceSize := func(chunk []md3Face) (ofsShaders, ofsTris, ofsST, ofsXYZ, ofsEnd int) {
+		ofsShaders = md3SurfaceHeaderSize
+		ofsTris = ofsShaders + 68
+		ofsST = ofsTris + len(chunk)*2*12
+		ofsXYZ = ofsST + len(chunk)*4*8
+		ofsEnd = ofsXYZ + len(chunk)*4*8*len(frames)
+		return
+	}
+
+	ofsFrames := md3HeaderSize
+	ofsTags := ofsFrames + len(frames)*56
+	ofsSurfaces := ofsTags
+	ofsEOF := ofsSurfaces
+	for _, chunk := range chunks {
+		_, _, _, _, size := surfaceSize(chunk)
+		ofsEOF += size
+	}
+
+	file := &errWriter{w: w}
+	_, _ = io.WriteString(file, "IDP3")
+	_ = binary.Write(file, binary.LittleEndian, int32(15))
+	writeMD3Name(file, frames[0].Name, 64)
+	for _, v := range []int{0, len(frames), 0, len(chunks), 0, ofsFrames, ofsTags, ofsSurfaces, ofsEOF} {
+		_ = binary.Write(file, binary.LittleEndian, int32(v))
+	}
+
+	// frame bounds:
+	for f, fr := range frames {
+		lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		radius := 0.0
+		for _, q := range fr.Quads {
+			for _, p := range q.Corners {
+				c := md3Vertex(p, fr.Origin)
+				for a := 0; a < 3; a++ {
+					lo[a] = math.Min(lo[a], c[a])
+					hi[a] = math.Max(hi[a], c[a])
+				}
+				radius = math.Max(radius, math.Sqrt(c[0]*c[0]+c[1]*c[1]+c[2]*c[2]))
+			}
+		}
+		if len(fr.Quads) == 0 {
+			lo, hi = [3]float64{}, [3]float64{}
+		}
+		for _, v := range []float64{lo[0], lo[1], lo[2], hi[0], hi[1], hi[2], 0, 0, 0, radius} {
+			_ = binary.Write(file, binary.LittleEndian, float32(v))
+		}
+		writeMD3Name(file, fmt.Sprintf("frame%d", f), 16)
+	}
+
+	for n, chunk := range chunks {
+		numVerts := len(chunk) * 4
+		numTris := len(chunk) * 2
+		ofsShaders, ofsTris, ofsST, ofsXYZ, ofsEnd := surfaceSize(chunk)
+
+		_, _ = io.WriteString(file, "IDP3")
+		writeMD3Name(file, fmt.Sprintf("surface%d", n), 64)
+		for _, v := range []int{0, len(frames), 1, numVerts, numTris, ofsTris, ofsShaders, ofsST, ofsXYZ, ofsEnd} {
+			_ = binary.Write(file, binary.LittleEndian, int32(v))
+		}
+
+		writeMD3Name(file, skin, 64)
+		_ = binary.Write(file, binary.LittleEndian, int32(0))
+
+		// MD3 triangles wind clockwise seen from the front, the opposite
+		// of the quads:
+		for i := range chunk {
+			base := int32(i * 4)
+			for _, k := range []int32{0, 2, 1, 0, 3, 2} {
+				_ = binary.Write(file, binary.LittleEndian, base+k)
+			}
+		}
+
+		for _, face := range chunk {
+			// MD3 has no emissive texture, so glowing faces look like the rest:
+			u, v := paletteUV(face.Quad.Color, false, 16)
+			for k := 0; k < 4; k++ {
+				// texture coordinates start at the top of the image:
+				_ = binary.Write(file, binary.LittleEndian, []float32{float32(u), float32(1 - v)})
+			}
+		}
+
+		for f := range frames {
+			for _, face := range chunk {
+				normal := md3Normal(md3Vertex(face.Quad.Normal, [3]int{}))
+				for _, c := range pos(face, f) {
+					for a := 0; a < 3; a++ {
+						_ = binary.Write(file, binary.LittleEndian, int16(math.Round(c[a]*md3Scale)))
+					}
+					_ = binary.Write(file, binary.LittleEndian, normal)
+				}
+			}
+		}
+	}
+
+	return file.err
+}
+
+// modelDef returns a MODELDEF entry that shows the model in place of the
+// sprite frames it was made from. Frame i of the model belongs to the frame
+// letter of frames[i].
+func modelDef(class, sprite, dir, model, skin string, frames []meshFrame) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Model %s\n{\n", class)
+	fmt.Fprintf(b, "\tPath \"%s\"\n", dir)
+	fmt.Fprintf(b, "\tModel 0 \"%s\"\n", model)
+	fmt.Fprintf(b, "\tSkin 0 \"%s\"\n", skin)
+	fmt.Fprintf(b, "\tScale 1.0 1.0 1.0\n")
+	b.WriteString("\n")
+	for i, fr := range frames {
+		fmt.Fprintf(b, "\tFrameIndex %s %c 0 %d\n", sprite, fr.Name[len(fr.Name)-1], i)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+func TestMD3Normal(t *testing.T) {
+	axes := [][3]float64{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	for _, n := range axes {
+		packed := md3Normal(n)
+		// unpacked as in Quake 3's renderer:
+		lat := float64(packed>>8) * 2 * math.Pi / 255
+		lng := float64(packed&0xFF) * 2 * math.Pi / 255
+		got := [3]float64{math.Cos(lat) * math.Sin(lng), math.Sin(lat) * math.Sin(lng), math.Cos(lng)}
+		for a := 0; a < 3; a++ {
+			if math.Abs(got[a]-n[a]) > 0.05 {
+				t.Errorf("md3Normal(%v) = %#04x, unpacks to %.3f", n, packed, got)
+				break
+			}
+		}
+	}
+}
+
+func TestWriteMD3SurfaceLimit(t *testing.T) {
+	quad := Quad{Corners: [4][3]int{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}}
+	for _, tt := range []struct {
+		quads int
+		ok    bool
+	}{
+		{1, true},
+		{md3MaxSurfaces * md3MaxVerts / 4, true},
+		{md3MaxSurfaces*md3MaxVerts/4 + 1, false},
+	} {
+		frames := []meshFrame{{Name: "A", Quads: make([]Quad, tt.quads)}}
+		for i := range frames[0].Quads {
+			frames[0].Quads[i] = quad
+		}
+		err := writeMD3(io.Discard, frames, "skin.png")
+		if (err == nil) != tt.ok {
+			t.Errorf("%d quads: err = %v", tt.quads, err)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Quad is one rectangular face of a voxel mesh, in voxel index space.
+type Quad struct {
+	// Corners are in counter-clockwise order seen from outside the model.
+	Corners [4][3]int
+	// Normal points out of the model along one axis.
+	Normal [3]int
+	Color  uint8
+	// Emissive is set on faces of glowing voxels.
+	Emissive bool
+	// Translatable is set on faces colored from the translation range.
+	Translatable bool
+}
+
+// GreedyMesh converts the surface of the volume into quads, merging
+// neighboring faces of the same color into rectangles.
+func (vol *Volume) GreedyMesh() (quads []Quad) {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return
+	}
+
+	for a := 0; a < 3; a++ {
+		// u and v span the face plane; u x v points along +a:
+		u := (a + 1) % 3
+		v := (a + 2) % 3
+		nu := hi[u] - lo[u] + 1
+		nv := hi[v] - lo[v] + 1
+
+		// mask holds color+1 of each visible face in the slice, 0 for none:
+		mask := make([]int, nu*nv)
+		for _, dir := range []int{-1, 1} {
+			for d := lo[a]; d <= hi[a]; d++ {
+				for j := 0; j < nv; j++ {
+					for i := 0; i < nu; i++ {
+						var p, n [3]int
+						p[a], p[u], p[v] = d, lo[u]+i, lo[v]+j
+						n = p
+						n[a] += dir
+
+						mask[j*nu+i] = 0
+						if vol.Filled[p[0]][p[1]][p[2]] && !vol.IsFilled(n[0], n[1], n[2]) {
+							mask[j*nu+i] = int(vol.Voxels[p[0]][p[1]][p[2]]) + 1
+							if vol.IsEmissive(p[0], p[1], p[2]) {
+								mask[j*nu+i] += 256
+							}
+						}
+					}
+				}
+
+				plane := d
+				if dir > 0 {
+					plane++
+				}
+
+				for j := 0; j < nv; j++ {
+					for i := 0; i < nu; {
+						c := mask[j*nu+i]
+						if c == 0 {
+							i++
+							continue
+						}
+
+						// grow along u, then along v while whole rows match:
+						w := 1
+						for i+w < nu && mask[j*nu+i+w] == c {
+							w++
+						}
+						h := 1
+					rows:
+						for j+h < nv {
+							for k := 0; k < w; k++ {
+								if mask[(j+h)*nu+i+k] != c {
+									break rows
+								}
+							}
+							h++
+						}
+						for y := 0; y < h; y++ {
+							for k := 0; k < w; k++ {
+								mask[(j+y)*nu+i+k] = 0
+							}
+						}
+
+						q := Quad{Color: uint8((c - 1) % 256), Emissive: c > 256}
+						q.Translatable = vol.Translation[q.Color]
+						q.Normal[a] = dir
+						corners := [4][2]int{{i, j}, {i + w, j}, {i + w, j + h}, {i, j + h}}
+						for k, uv := range corners {
+							// faces looking down -a wind the other way round:
+							if dir < 0 {
+								uv = corners[3-k]
+							}
+							q.Corners[k][a] = plane
+							q.Corners[k][u] = lo[u] + uv[0]
+							q.Corners[k][v] = lo[v] + uv[1]
+						}
+						quads = append(quads, q)
+
+						i += w
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// meshVertex converts a corner in voxel index space to mesh coordinates: one
+// unit per voxel, relative to the same origin as the VOX export, and turned
+// Y-up with the front of the model facing +Z as most mesh tools expect.
+func meshVertex(p, origin [3]int) [3]int {
+	return [3]int{p[0] - origin[0], p[2] - origin[2], -(p[1] - origin[1])}
+}
+
+// meshMaterials names the materials of exported meshes: the palette, and a
+// copy of it for the faces of the translation range so that they can be
+// recolored on their own.
+var meshMaterials = []string{"palette", "translation"}
+
+// material returns the index of the quad's material in meshMaterials.
+func (q Quad) material() int {
+	if q.Translatable {
+		return 1
+	}
+	return 0
+}
+
+// materialCount returns how many of meshMaterials the quads need: just the
+// palette unless some face is translatable.
+func materialCount(quads ...[]Quad) int {
+	for _, qs := range quads {
+		for _, q := range qs {
+			if q.Translatable {
+				return 2
+			}
+		}
+	}
+	return 1
+}
+
+// paletteRows returns the height of the palette texture for the quads: 16
+// rows, doubled if any face glows so that glowing faces can sample a second
+// copy of the palette which the emissive texture lights.
+func paletteRows(quads ...[]Quad) int {
+	for _, qs := range quads {
+		for _, q := range qs {
+			if q.Emissive {
+				return 32
+			}
+		}
+	}
+	return 16
+}
+
+// paletteUV returns the texture coordinates of the center of a palette
+// entry's texel in a palette texture of the given number of rows; glowing
+// faces use the lower copy of the palette.
+func paletteUV(c uint8, glow bool, rows int) (u, v float64) {
+	row := int(c / 16)
+	if glow && rows > 16 {
+		row += 16
+	}
+	u = (float64(c%16) + 0.5) / 16.0
+	v = 1.0 - (float64(row)+0.5)/float64(rows)
+	return
+}
+
+// paletteTexture lays out the palette as a 16 pixel wide image, one texel per
+// entry, repeated to fill the given number of rows.
+func paletteTexture(pal color.Palette, rows int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, 16, rows), pal)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	return img
+}
+
+// emissiveTexture is the emissive counterpart of a 32 row palette texture:
+// black for the upper copy of the palette and the palette colors for the
+// lower copy used by glowing faces.
+func emissiveTexture(pal color.Palette) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 32))
+	for i := 0; i < 256; i++ {
+		img.Set(i%16, i/16, color.Black)
+		img.Set(i%16, 16+i/16, rgbaOf(pal[i]))
+	}
+	return img
+}
+
+// saveOBJ writes the mesh as a Wavefront OBJ with an MTL file and a palette
+// texture next to it, named after objPath. Each face samples its color from
+// the texture; glowing faces also get an emissive texture.
+func saveOBJ(objPath string, quads []Quad, origin [3]int, pal color.Palette) (err error) {
+	base := strings.TrimSuffix(objPath, filepath.Ext(objPath))
+	mtlPath := base + ".mtl"
+	texPath := base + "-pal.png"
+	emitPath := base + "-emit.png"
+	rows := paletteRows(quads)
+
+	if err = savePNG(texPath, paletteTexture(pal, rows)); err != nil {
+		return
+	}
+	if rows > 16 {
+		if err = savePNG(emitPath, emissiveTexture(pal)); err != nil {
+			return
+		}
+	}
+
+	err = writeFileAtomic(mtlPath, func(w io.Writer) error {
+		ew := &errWriter{w: w}
+		for _, name := range meshMaterials[:materialCount(quads)] {
+			fmt.Fprintf(ew, "newmtl %s\nKa 1 1 1\nKd 1 1 1\nKs 0 0 0\nillum 1\nmap_Kd %s\n", name, filepath.Base(texPath))
+			if rows > 16 {
+				fmt.Fprintf(ew, "Ke 1 1 1\nmap_Ke %s\n", filepath.Base(emitPath))
+			}
+		}
+		return ew.err
+	})
+	if err != nil {
+		return
+	}
+
+	return writeFileAtomic(objPath, func(w io.Writer) error {
+		return writeOBJ(w, quads, origin, filepath.Base(mtlPath))
+	})
+}
+
+// writeOBJ writes the mesh as a Wavefront OBJ using the materials of the
+// given MTL file.
+func writeOBJ(w io.Writer, quads []Quad, origin [3]int, mtl string) error {
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, "mtllib %s\n", mtl)
+
+	// one texture coordinate per palette entry, then one per glowing entry
+	// if any face glows, and one normal per axis direction, in the order of
+	// normalIndex:
+	rows := paletteRows(quads)
+	for i := 0; i < 256*rows/16; i++ {
+		u, v := paletteUV(uint8(i), i >= 256, rows)
+		fmt.Fprintf(ew, "vt %g %g\n", u, v)
+	}
+	for _, n := range objNormals {
+		m := meshVertex(n, [3]int{})
+		fmt.Fprintf(ew, "vn %d %d %d\n", m[0], m[1], m[2])
+	}
+
+	// vertices are shared between quads where they coincide:
+	index := map[[3]int]int{}
+	for _, q := range quads {
+		for _, p := range q.Corners {
+			if _, ok := index[p]; ok {
+				continue
+			}
+			index[p] = len(index) + 1
+			m := meshVertex(p, origin)
+			fmt.Fprintf(ew, "v %d %d %d\n", m[0], m[1], m[2])
+		}
+	}
+
+	for mat, name := range meshMaterials[:materialCount(quads)] {
+		fmt.Fprintf(ew, "usemtl %s\n", name)
+		for _, q := range quads {
+			if q.material() != mat {
+				continue
+			}
+			vt := int(q.Color) + 1
+			if q.Emissive {
+				vt += 256
+			}
+			fmt.Fprintf(ew, "f")
+			for _, p := range q.Corners {
+				fmt.Fprintf(ew, " %d/%d/%d", index[p], vt, normalIndex(q.Normal))
+			}
+			fmt.Fprintf(ew, "\n")
+		}
+	}
+	return ew.err
+}
+
+var objNormals = [6][3]int{{-1, 0, 0}, {1, 0, 0}, {0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}}
+
+// normalIndex returns the 1-based OBJ index of an axis-aligned normal.
+func normalIndex(n [3]int) int {
+	for i, m := range objNormals {
+		if m == n {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func savePLY(plyPath string, quads []Quad, origin [3]int, pal color.Palette) error {
+	return writeFileAtomic(plyPath, func(w io.Writer) error {
+		return writePLY(w, quads, origin, pal)
+	})
+}
+
+// writePLY writes the mesh as an ASCII PLY with per-vertex colors. Quads do
+// not share vertices so that every face keeps its own color.
+func writePLY(w io.Writer, quads []Quad, origin [3]int, pal color.Palette) error {
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, "ply\nformat ascii 1.0\n")
+	fmt.Fprintf(ew, "element vertex %d\n", len(quads)*4)
+	fmt.Fprintf(ew, "property float x\nproperty float y\nproperty float z\n")
+	fmt.Fprintf(ew, "property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	fmt.Fprintf(ew, "element face %d\n", len(quads))
+	fmt.Fprintf(ew, "property list uchar int vertex_indices\n")
+	fmt.Fprintf(ew, "end_header\n")
+
+	for _, q := range quads {
+		c := rgbaOf(pal[q.Color])
+		for _, p := range q.Corners {
+			m := meshVertex(p, origin)
+			fmt.Fprintf(ew, "%d %d %d %d %d %d\n", m[0], m[1], m[2], c.R, c.G, c.B)
+		}
+	}
+	for i := range quads {
+		fmt.Fprintf(ew, "4 %d %d %d %d\n", i*4, i*4+1, i*4+2, i*4+3)
+	}
+	return ew.err
+}
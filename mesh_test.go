@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// countPrefixes counts the lines of s by their first word.
+func countPrefixes(s string) map[string]int {
+	counts := map[string]int{}
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		if f := strings.Fields(sc.Text()); len(f) > 0 {
+			counts[f[0]]++
+		}
+	}
+	return counts
+}
+
+func TestGreedyMeshSingleVoxel(t *testing.T) {
+	vol := NewVolume(3, 3, 3)
+	vol.Filled[1][1][1] = true
+	vol.Voxels[1][1][1] = 42
+
+	quads := vol.GreedyMesh()
+	if len(quads) != 6 {
+		t.Fatalf("%d quads, want 6", len(quads))
+	}
+	normals := map[[3]int]bool{}
+	for _, q := range quads {
+		normals[q.Normal] = true
+		if q.Color != 42 {
+			t.Errorf("quad color %d, want 42", q.Color)
+		}
+	}
+	if len(normals) != 6 {
+		t.Errorf("%d distinct normals, want 6", len(normals))
+	}
+}
+
+func TestWriteOBJ(t *testing.T) {
+	vol := NewVolume(3, 3, 3)
+	vol.Filled[1][1][1] = true
+	vol.Voxels[1][1][1] = 42
+
+	var b bytes.Buffer
+	if err := writeOBJ(&b, vol.GreedyMesh(), [3]int{1, 1, 1}, "model.mtl"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.HasPrefix(out, "mtllib model.mtl\n") {
+		t.Errorf("OBJ starts with %q", strings.SplitN(out, "\n", 2)[0])
+	}
+
+	// the eight corners are shared by the six faces:
+	counts := countPrefixes(out)
+	want := map[string]int{"mtllib": 1, "vt": 256, "vn": 6, "v": 8, "usemtl": 1, "f": 6}
+	for k, n := range want {
+		if counts[k] != n {
+			t.Errorf("%d %q lines, want %d", counts[k], k, n)
+		}
+	}
+	if !strings.Contains(out, "\nusemtl palette\n") {
+		t.Error("no palette material")
+	}
+	// every face samples entry 42 of the palette texture:
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "f ") && !strings.Contains(line, "/43/") {
+			t.Errorf("face %q does not use texture coordinate 43", line)
+		}
+	}
+	for _, v := range []string{"v 0 0 0\n", "v 1 1 -1\n"} {
+		if !strings.Contains(out, v) {
+			t.Errorf("no vertex %q", strings.TrimSpace(v))
+		}
+	}
+}
+
+func TestWritePLY(t *testing.T) {
+	pal := testPalette()
+	vol := NewVolume(3, 3, 3)
+	vol.Filled[1][1][1] = true
+	vol.Voxels[1][1][1] = 42
+
+	var b bytes.Buffer
+	if err := writePLY(&b, vol.GreedyMesh(), [3]int{1, 1, 1}, pal); err != nil {
+		t.Fatal(err)
+	}
+	header, body, ok := strings.Cut(b.String(), "end_header\n")
+	if !ok {
+		t.Fatal("no end_header")
+	}
+	for _, line := range []string{"ply", "format ascii 1.0", "element vertex 24", "element face 6"} {
+		if !strings.Contains(header, line+"\n") {
+			t.Errorf("header lacks %q", line)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != 24+6 {
+		t.Fatalf("%d body lines, want 30", len(lines))
+	}
+	c := rgbaOf(pal[42])
+	rgb := fmt.Sprintf(" %d %d %d", c.R, c.G, c.B)
+	for _, line := range lines[:24] {
+		if !strings.HasSuffix(line, rgb) {
+			t.Errorf("vertex %q is not colored%s", line, rgb)
+		}
+	}
+	if lines[24] != "4 0 1 2 3" || lines[29] != "4 20 21 22 23" {
+		t.Errorf("faces %q .. %q", lines[24], lines[29])
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// errWriter remembers the first write error and drops everything after it,
+// so that writers can emit a whole file and check for errors once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (n int, err error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, ew.err = ew.w.Write(p)
+	return n, ew.err
+}
+
+// writeFileAtomic creates path with the contents produced by write. The data
+// goes to a temporary file in the same directory which is renamed over path
+// once complete, so a failed or interrupted write never leaves a partial file.
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	bw := bufio.NewWriterSize(f, 64*1024)
+	if err = write(bw); err != nil {
+		return
+	}
+	if err = bw.Flush(); err != nil {
+		return
+	}
+	if err = f.Chmod(0644); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), path)
+}
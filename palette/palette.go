@@ -0,0 +1,94 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Size is the number of entries in a palette and in a colormap table.
+const Size = 256
+
+// COLORMAP tables, as used by Doom:
+const (
+	// Levels is the number of light levels; level 0 is full brightness,
+	// which is also what fullbright sprites are drawn with.
+	Levels = 32
+	// Invulnerability is the inverted greyscale table of the invulnerability
+	// power-up.
+	Invulnerability = 32
+)
+
+// PLAYPAL holds every palette of a PLAYPAL lump. Doom has 14: the normal
+// palette, then red pain/berserk tints, yellow item pickup tints and the
+// green radiation suit palette.
+type PLAYPAL []color.Palette
+
+// ParsePLAYPAL reads all palettes of a PLAYPAL lump.
+func ParsePLAYPAL(data []byte) (PLAYPAL, error) {
+	if len(data) < Size*3 {
+		return nil, fmt.Errorf("PLAYPAL is %d bytes; need at least %d for one palette", len(data), Size*3)
+	}
+
+	pals := make(PLAYPAL, len(data)/(Size*3))
+	for p := range pals {
+		pal := make(color.Palette, Size)
+		for i := range pal {
+			o := (p*Size + i) * 3
+			pal[i] = color.RGBA{R: data[o], G: data[o+1], B: data[o+2], A: 0xFF}
+		}
+		pals[p] = pal
+	}
+	return pals, nil
+}
+
+// COLORMAP holds the tables of a COLORMAP lump, each mapping a palette index
+// to the index drawn in its place.
+type COLORMAP [][Size]uint8
+
+// ParseCOLORMAP reads all tables of a COLORMAP lump.
+func ParseCOLORMAP(data []byte) (COLORMAP, error) {
+	if len(data) < Size {
+		return nil, fmt.Errorf("COLORMAP is %d bytes; need at least %d for one table", len(data), Size)
+	}
+
+	cm := make(COLORMAP, len(data)/Size)
+	for t := range cm {
+		copy(cm[t][:], data[t*Size:(t+1)*Size])
+	}
+	return cm, nil
+}
+
+// Bake returns the palette as it appears through colormap table t, so that
+// index i shows the color Doom draws for index i at that light level. Indices
+// set in fullbright are drawn through table 0, as fullbright pixels are.
+func (cm COLORMAP) Bake(pal color.Palette, t int, fullbright [Size]bool) (color.Palette, error) {
+	if t < 0 || t >= len(cm) {
+		return nil, fmt.Errorf("colormap table %d out of range; COLORMAP has %d", t, len(cm))
+	}
+
+	baked := make(color.Palette, len(pal))
+	for i := range pal {
+		table := t
+		if i < Size && fullbright[i] {
+			table = 0
+		}
+		baked[i] = pal[cm[table][i]]
+	}
+	return baked, nil
+}
+
+// Fullbright reports the indices every light level leaves unchanged. Black
+// always is; palettes that reserve fullbright colors, as many mods do, show
+// them here as well.
+func (cm COLORMAP) Fullbright() (bright [Size]bool) {
+	for i := range bright {
+		bright[i] = true
+		for t := 0; t < Levels && t < len(cm); t++ {
+			if cm[t][i] != uint8(i) {
+				bright[i] = false
+				break
+			}
+		}
+	}
+	return
+}
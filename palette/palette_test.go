@@ -0,0 +1,37 @@
+package palette
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestBake(t *testing.T) {
+	pal := make(color.Palette, Size)
+	for i := range pal {
+		pal[i] = color.RGBA{R: uint8(i), A: 0xFF}
+	}
+	// table t maps every index i to i/(t+1), table 0 to itself:
+	cm := make(COLORMAP, Levels)
+	for tbl := range cm {
+		for i := range cm[tbl] {
+			cm[tbl][i] = uint8(i / (tbl + 1))
+		}
+	}
+
+	var fullbright [Size]bool
+	fullbright[200] = true
+	baked, err := cm.Bake(pal, 3, fullbright)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := baked[100].(color.RGBA).R; got != 25 {
+		t.Errorf("index 100 baked to %d, want 25", got)
+	}
+	if got := baked[200].(color.RGBA).R; got != 200 {
+		t.Errorf("fullbright index 200 baked to %d, want 200", got)
+	}
+
+	if _, err := cm.Bake(pal, Levels, fullbright); err == nil {
+		t.Error("no error for a table out of range")
+	}
+}
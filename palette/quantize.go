@@ -0,0 +1,104 @@
+package palette
+
+import (
+	"image/color"
+	"sort"
+)
+
+// MedianCut builds a palette of at most n colors for the given colors by
+// repeatedly splitting the box with the widest channel range at its median.
+// Each entry is the average of the colors in its box; fewer distinct colors
+// than n are kept exactly.
+func MedianCut(colors []color.RGBA, n int) color.Palette {
+	counts := map[color.RGBA]int{}
+	for _, c := range colors {
+		c.A = 0xFF
+		counts[c]++
+	}
+	if len(counts) == 0 || n <= 0 {
+		return color.Palette{}
+	}
+
+	distinct := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		distinct = append(distinct, c)
+	}
+	// map order is random; keep the result stable:
+	sort.Slice(distinct, func(i, j int) bool {
+		a, b := distinct[i], distinct[j]
+		return uint32(a.R)<<16|uint32(a.G)<<8|uint32(a.B) < uint32(b.R)<<16|uint32(b.G)<<8|uint32(b.B)
+	})
+
+	boxes := [][]color.RGBA{distinct}
+	for len(boxes) < n {
+		// the box with the widest channel range:
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 3; ch++ {
+				lo, hi := 255, 0
+				for _, c := range box {
+					v := channel(c, ch)
+					if v < lo {
+						lo = v
+					}
+					if v > hi {
+						hi = v
+					}
+				}
+				if hi-lo > bestRange {
+					best, bestChannel, bestRange = i, ch, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		// split at the weighted median so popular colors get their own boxes:
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool {
+			return channel(box[i], bestChannel) < channel(box[j], bestChannel)
+		})
+		total := 0
+		for _, c := range box {
+			total += counts[c]
+		}
+		split, seen := 1, 0
+		for i, c := range box[:len(box)-1] {
+			seen += counts[c]
+			split = i + 1
+			if seen*2 >= total {
+				break
+			}
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	pal := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		var r, g, b, w int
+		for _, c := range box {
+			k := counts[c]
+			r += int(c.R) * k
+			g += int(c.G) * k
+			b += int(c.B) * k
+			w += k
+		}
+		pal[i] = color.RGBA{R: uint8((r + w/2) / w), G: uint8((g + w/2) / w), B: uint8((b + w/2) / w), A: 0xFF}
+	}
+	return pal
+}
+
+func channel(c color.RGBA, ch int) int {
+	switch ch {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	}
+	return int(c.B)
+}
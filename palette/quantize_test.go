@@ -0,0 +1,59 @@
+package palette
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestMedianCutExact(t *testing.T) {
+	// 256 distinct colors, some of them many times over:
+	var colors []color.RGBA
+	want := map[color.RGBA]bool{}
+	for i := 0; i < Size; i++ {
+		c := color.RGBA{R: uint8(i), G: uint8(i * 37), B: uint8(255 - i), A: 0xFF}
+		want[c] = true
+		for k := 0; k <= i%5; k++ {
+			colors = append(colors, c)
+		}
+	}
+
+	for _, n := range []int{Size, Size + 10} {
+		pal := MedianCut(colors, n)
+		if len(pal) != Size {
+			t.Errorf("n=%d: %d colors, want %d", n, len(pal), Size)
+		}
+		got := map[color.RGBA]bool{}
+		for _, c := range pal {
+			got[c.(color.RGBA)] = true
+		}
+		for c := range want {
+			if !got[c] {
+				t.Errorf("n=%d: %v lost", n, c)
+			}
+		}
+	}
+
+	// a few colors stay a few colors:
+	pal := MedianCut([]color.RGBA{{R: 1, A: 0xFF}, {G: 2, A: 0xFF}, {R: 1, A: 0xFF}}, Size)
+	if len(pal) != 2 {
+		t.Errorf("two colors became %d", len(pal))
+	}
+}
+
+func TestMedianCutLimit(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	colors := make([]color.RGBA, 5000)
+	for i := range colors {
+		colors[i] = color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 0xFF}
+	}
+
+	for _, n := range []int{1, 2, 15, 255, 256} {
+		if pal := MedianCut(colors, n); len(pal) != n {
+			t.Errorf("n=%d: %d colors", n, len(pal))
+		}
+	}
+	if pal := MedianCut(colors, 0); len(pal) != 0 {
+		t.Errorf("n=0: %d colors", len(pal))
+	}
+}
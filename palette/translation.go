@@ -0,0 +1,47 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Translation remaps palette indices the way the engine recolors translatable
+// sprites, e.g. the green player range for other players in multiplayer.
+type Translation [Size]uint8
+
+// PlayerRange is the green range of the player sprites that Doom translates.
+const PlayerRange = "112-127"
+
+// NewTranslation maps the k-th index of the translatable range onto index
+// base+k%16, as Doom's translation tables do, and leaves the rest unchanged.
+func NewTranslation(translatable [Size]bool, base uint8) (t Translation) {
+	k := 0
+	for i := range t {
+		t[i] = uint8(i)
+		if translatable[i] {
+			t[i] = base + uint8(k%16)
+			k++
+		}
+	}
+	return
+}
+
+// Apply returns the palette as it appears through the translation, so that
+// index i shows the color drawn for index i on a translated sprite.
+func (t Translation) Apply(pal color.Palette) color.Palette {
+	translated := make(color.Palette, len(pal))
+	for i := range pal {
+		translated[i] = pal[t[i]]
+	}
+	return translated
+}
+
+// ParseTranslation reads a translation table lump such as Hexen's TRANTBL0,
+// which holds the translated index of each of the 256 palette indices.
+func ParseTranslation(data []byte) (t Translation, err error) {
+	if len(data) < Size {
+		return t, fmt.Errorf("translation table is %d bytes, want %d", len(data), Size)
+	}
+	copy(t[:], data)
+	return
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// maxPostLength keeps posts short enough for vanilla-compatible renderers.
+const maxPostLength = 128
+
+// encodePatch converts a paletted image into the Doom patch format. Pixels
+// whose mask alpha is 0 are left transparent.
+func encodePatch(img *image.Paletted, mask *image.Alpha, leftoffs, topoffs int) []byte {
+	b := img.Rect
+	width := b.Dx()
+	height := b.Dy()
+
+	out := &bytes.Buffer{}
+	_ = binary.Write(out, binary.LittleEndian, uint16(width))
+	_ = binary.Write(out, binary.LittleEndian, uint16(height))
+	_ = binary.Write(out, binary.LittleEndian, int16(leftoffs))
+	_ = binary.Write(out, binary.LittleEndian, int16(topoffs))
+
+	// column offsets are filled in once the posts are written:
+	colOffs := out.Len()
+	out.Write(make([]byte, 4*width))
+
+	offsets := make([]uint32, width)
+	for i := 0; i < width; i++ {
+		offsets[i] = uint32(out.Len())
+		x := b.Min.X + i
+
+		// absolute row of the previous post, for tall patch encoding:
+		lastTop := -1
+		writePost := func(top int, pixels []byte) {
+			if top <= 254 && top > lastTop {
+				lastTop = top
+			} else {
+				// tall patches: a topdelta not greater than the previous
+				// post's top is relative to it, so climb with empty posts
+				// until the remaining distance can be expressed:
+				if lastTop <= 0 {
+					out.Write([]byte{254, 0, 0, 0})
+					lastTop = 254
+				}
+				for top-lastTop > lastTop || top-lastTop > 254 {
+					rel := lastTop
+					if rel > 254 {
+						rel = 254
+					}
+					out.Write([]byte{byte(rel), 0, 0, 0})
+					lastTop += rel
+				}
+				top, lastTop = top-lastTop, top
+			}
+
+			out.WriteByte(byte(top))
+			out.WriteByte(byte(len(pixels)))
+			// unused padding byte
+			out.WriteByte(0)
+			out.Write(pixels)
+			out.WriteByte(0)
+		}
+
+		var run []byte
+		runStart := 0
+		for j := 0; j < height; j++ {
+			y := b.Min.Y + j
+			if mask.AlphaAt(x, y).A == 0 {
+				if len(run) > 0 {
+					writePost(runStart, run)
+					run = nil
+				}
+				continue
+			}
+
+			if len(run) == 0 {
+				runStart = j
+			}
+			run = append(run, img.ColorIndexAt(x, y))
+			if len(run) == maxPostLength {
+				writePost(runStart, run)
+				run = nil
+			}
+		}
+		if len(run) > 0 {
+			writePost(runStart, run)
+		}
+
+		// end of column
+		out.WriteByte(0xFF)
+	}
+
+	data := out.Bytes()
+	for i, o := range offsets {
+		le.PutUint32(data[colOffs+i*4:colOffs+i*4+4], o)
+	}
+
+	return data
+}
+
+// decodePatch converts a patch in the Doom patch format into a paletted
+// image and a mask of its opaque pixels, reading tall patches whose
+// topdeltas restart relative to the previous post.
+func decodePatch(data []byte, pal color.Palette) (img *image.Paletted, mask *image.Alpha, leftoffs, topoffs int, err error) {
+	if len(data) < 8 {
+		return nil, nil, 0, 0, fmt.Errorf("patch is %d bytes, too short for a header", len(data))
+	}
+	width := int(le.Uint16(data[0:2]))
+	height := int(le.Uint16(data[2:4]))
+	leftoffs = int(int16(le.Uint16(data[4:6])))
+	topoffs = int(int16(le.Uint16(data[6:8])))
+	if len(data) < 8+width*4 {
+		return nil, nil, 0, 0, fmt.Errorf("patch is %d bytes, too short for %d columns", len(data), width)
+	}
+
+	rect := image.Rect(0, 0, width, height)
+	img = image.NewPaletted(rect, pal)
+	mask = image.NewAlpha(rect)
+	for x := 0; x < width; x++ {
+		offs := int(le.Uint32(data[8+x*4:]))
+		lastTop := -1
+		for offs < len(data) && data[offs] != 0xFF {
+			if offs+3 > len(data) {
+				return nil, nil, 0, 0, fmt.Errorf("column %d runs past the end of the patch", x)
+			}
+			top := int(data[offs])
+			if top <= lastTop {
+				top += lastTop
+			}
+			lastTop = top
+			length := int(data[offs+1])
+			pixels := data[offs+3:]
+			if len(pixels) < length {
+				return nil, nil, 0, 0, fmt.Errorf("column %d runs past the end of the patch", x)
+			}
+			for j := 0; j < length; j++ {
+				if y := top + j; y < height {
+					img.SetColorIndex(x, y, pixels[j])
+					mask.SetAlpha(x, y, color.Alpha{A: 0xFF})
+				}
+			}
+			// topdelta, length, unused byte, pixels, unused byte:
+			offs += 4 + length
+		}
+	}
+	return
+}
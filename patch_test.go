@@ -0,0 +1,79 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPatchRoundTrip(t *testing.T) {
+	const width, height = 6, 600
+	img := image.NewPaletted(image.Rect(0, 0, width, height), testPalette())
+	mask := image.NewAlpha(img.Rect)
+	opaque := []func(y int) bool{
+		// a full tall column, split into posts of maxPostLength:
+		func(y int) bool { return true },
+		// stripes with transparent gaps:
+		func(y int) bool { return y%50 < 30 },
+		// nothing:
+		func(y int) bool { return false },
+		// single pixels around the 254 and 255 topdelta limits and past
+		// twice that:
+		func(y int) bool { return y == 0 || y == 254 || y == 255 || y == 256 || y == 509 || y == 599 },
+		// one post starting past 254:
+		func(y int) bool { return y >= 300 && y < 310 },
+		// a gap longer than 254 between two posts:
+		func(y int) bool { return y < 3 || y > 590 },
+	}
+	for x, f := range opaque {
+		for y := 0; y < height; y++ {
+			if f(y) {
+				img.SetColorIndex(x, y, uint8(x*31+y))
+				mask.Pix[mask.PixOffset(x, y)] = 0xFF
+			}
+		}
+	}
+
+	data := encodePatch(img, mask, -3, 550)
+	if !isPatch(data) {
+		t.Fatal("encoded data is not a valid patch")
+	}
+	got, gotMask, leftoffs, topoffs, err := decodePatch(data, testPalette())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rect != img.Rect || leftoffs != -3 || topoffs != 550 {
+		t.Fatalf("decoded %v at %d, %d; want %v at -3, 550", got.Rect, leftoffs, topoffs, img.Rect)
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			want := mask.AlphaAt(x, y).A
+			if a := gotMask.AlphaAt(x, y).A; a != want {
+				t.Errorf("pixel %d,%d: alpha %d, want %d", x, y, a, want)
+				continue
+			}
+			if want != 0 && got.ColorIndexAt(x, y) != img.ColorIndexAt(x, y) {
+				t.Errorf("pixel %d,%d: color %d, want %d", x, y, got.ColorIndexAt(x, y), img.ColorIndexAt(x, y))
+			}
+		}
+	}
+}
+
+func TestDecodePatchErrors(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 2, 2), testPalette())
+	mask := image.NewAlpha(img.Rect)
+	for i := range mask.Pix {
+		mask.Pix[i] = 0xFF
+	}
+	data := encodePatch(img, mask, 0, 0)
+
+	for _, b := range [][]byte{
+		data[:4],
+		data[:12],
+		// cut off in the middle of the last post:
+		data[:len(data)-3],
+	} {
+		if _, _, _, _, err := decodePatch(b, testPalette()); err == nil {
+			t.Errorf("%d bytes: no error", len(b))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+func saveQB(path string, vol *Volume, pal color.Palette, name string) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		return writeQB(w, vol, pal, name)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeQB writes the volume as a Qubicle binary file with one uncompressed
+// RGBA matrix cropped to the model. Qubicle is Y up, so the voxel Z axis is
+// stored as Y; that swap turns the file left-handed, which the header says.
+// The matrix position places the VOX export origin at Qubicle's origin.
+func writeQB(w io.Writer, vol *Volume, pal color.Palette, name string) error {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return fmt.Errorf("model is empty")
+	}
+	origin := voxOrigin(vol)
+
+	file := &errWriter{w: w}
+	for _, v := range []uint32{
+		0x00000101, // version 1.1.0.0
+		0,          // color format: RGBA
+		0,          // z axis orientation: left-handed
+		0,          // not compressed
+		0,          // visibility mask not encoded: alpha is 0 or 255
+		1,          // number of matrices
+	} {
+		_ = binary.Write(file, binary.LittleEndian, v)
+	}
+
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	_, _ = file.Write(append([]byte{uint8(len(name))}, name...))
+
+	// sizes and position in Qubicle's axis order:
+	size := [3]int{hi[0] - lo[0] + 1, hi[2] - lo[2] + 1, hi[1] - lo[1] + 1}
+	pos := [3]int{lo[0] - origin[0], lo[2] - origin[2], lo[1] - origin[1]}
+	for _, v := range size {
+		_ = binary.Write(file, binary.LittleEndian, uint32(v))
+	}
+	for _, v := range pos {
+		_ = binary.Write(file, binary.LittleEndian, int32(v))
+	}
+
+	row := make([]byte, 4*size[0])
+	for qz := 0; qz < size[2]; qz++ {
+		for qy := 0; qy < size[1]; qy++ {
+			for qx := 0; qx < size[0]; qx++ {
+				x, y, z := lo[0]+qx, lo[1]+qz, lo[2]+qy
+				c := color.RGBA{}
+				if vol.Filled[x][y][z] {
+					c = rgbaOf(pal[vol.Voxels[x][y][z]])
+					c.A = 0xFF
+				}
+				copy(row[qx*4:], []byte{c.R, c.G, c.B, c.A})
+			}
+			_, _ = file.Write(row)
+		}
+	}
+
+	return file.err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteQB(t *testing.T) {
+	pal := testPalette()
+	vol := NewVolume(4, 4, 4)
+	vol.Pivot.X, vol.Pivot.Y = 1.5, 0.5
+	vol.Filled[2][1][3] = true
+	vol.Voxels[2][1][3] = 42
+
+	var b bytes.Buffer
+	if err := writeQB(&b, vol, pal, "CYBRA"); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	le := binary.LittleEndian
+
+	// version, color format, orientation, compression, mask, matrix count:
+	want := []uint32{0x00000101, 0, 0, 0, 0, 1}
+	for i, v := range want {
+		if got := le.Uint32(data[i*4:]); got != v {
+			t.Errorf("header field %d = %#x, want %#x", i, got, v)
+		}
+	}
+	data = data[24:]
+	if n := int(data[0]); n != 5 || string(data[1:1+n]) != "CYBRA" {
+		t.Fatalf("matrix name %q", data[1:1+n])
+	}
+	data = data[6:]
+
+	if len(data) != 12+12+4 {
+		t.Fatalf("%d bytes after the name, want 28", len(data))
+	}
+	for a := 0; a < 3; a++ {
+		if size := le.Uint32(data[a*4:]); size != 1 {
+			t.Errorf("size %d = %d, want 1", a, size)
+		}
+	}
+	// Qubicle order is x, z, y, relative to the VOX origin (1,0,0):
+	wantPos := [3]int32{1, 3, 1}
+	for a := 0; a < 3; a++ {
+		if pos := int32(le.Uint32(data[12+a*4:])); pos != wantPos[a] {
+			t.Errorf("position %d = %d, want %d", a, pos, wantPos[a])
+		}
+	}
+	c := rgbaOf(pal[42])
+	if got := data[24:]; !bytes.Equal(got, []byte{c.R, c.G, c.B, 0xFF}) {
+		t.Errorf("voxel % x, want %v", got, c)
+	}
+
+	if err := writeQB(&b, NewVolume(4, 4, 4), pal, "CYBRA"); err == nil {
+		t.Error("empty model written")
+	}
+}
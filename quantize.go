@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// bayer4 is the 4x4 ordered dithering matrix.
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Quantize replaces the colors of the volume by indices into target, taking
+// each voxel's truecolor or else its entry in decode, and drops the
+// truecolors. dither is none, ordered (a Bayer pattern on every face plane)
+// or diffuse (error diffusion over the surface, forward along each axis).
+func (vol *Volume) Quantize(decode, target color.Palette, dither string) error {
+	if len(target) == 0 {
+		return fmt.Errorf("empty target palette")
+	}
+	if dither != "none" && dither != "ordered" && dither != "diffuse" {
+		return fmt.Errorf("dither must be none, ordered or diffuse, got %q", dither)
+	}
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		vol.Truecolor = nil
+		return nil
+	}
+
+	nearest := map[color.RGBA]uint8{}
+	index := func(c color.RGBA) uint8 {
+		i, ok := nearest[c]
+		if !ok {
+			i = uint8(target.Index(c))
+			nearest[c] = i
+		}
+		return i
+	}
+
+	surface := func(x, y, z int) bool {
+		for _, d := range [6][3]int{{-1, 0, 0}, {1, 0, 0}, {0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}} {
+			if !vol.IsFilled(x+d[0], y+d[1], z+d[2]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// ordered dithering spreads each channel by about one palette step:
+	spread := 256 / math.Cbrt(float64(len(target)))
+	errs := map[[3]int][3]float64{}
+
+	quantized := newVolumeIndices(vol.MaxX, vol.MaxY, vol.MaxZ, lo, hi)
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				if !vol.Filled[x][y][z] {
+					continue
+				}
+				c := vol.ColorAt(x, y, z, decode)
+				if dither == "none" || !surface(x, y, z) {
+					quantized[x][y][z] = index(c)
+					continue
+				}
+
+				want := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+				switch dither {
+				case "ordered":
+					t := (bayer4[(x+z)&3][(y+z)&3]+0.5)/16 - 0.5
+					for k := range want {
+						want[k] += t * spread
+					}
+				case "diffuse":
+					e := errs[[3]int{x, y, z}]
+					delete(errs, [3]int{x, y, z})
+					for k := range want {
+						want[k] += e[k]
+					}
+				}
+				for k := range want {
+					want[k] = math.Max(0, math.Min(255, want[k]))
+				}
+				i := index(color.RGBA{R: uint8(math.Round(want[0])), G: uint8(math.Round(want[1])), B: uint8(math.Round(want[2])), A: 0xFF})
+				quantized[x][y][z] = i
+
+				if dither == "diffuse" {
+					got := rgbaOf(target[i])
+					diff := [3]float64{want[0] - float64(got.R), want[1] - float64(got.G), want[2] - float64(got.B)}
+
+					// half the error goes along X and a quarter along Y and
+					// Z, shared among the surface neighbors that exist:
+					next := [3][3]int{{x + 1, y, z}, {x, y + 1, z}, {x, y, z + 1}}
+					weights := [3]float64{0.5, 0.25, 0.25}
+					total := 0.0
+					for n, p := range next {
+						if !vol.IsFilled(p[0], p[1], p[2]) || !surface(p[0], p[1], p[2]) {
+							weights[n] = 0
+						}
+						total += weights[n]
+					}
+					for n, p := range next {
+						if weights[n] == 0 {
+							continue
+						}
+						e := errs[p]
+						for k := range e {
+							e[k] += diff[k] * weights[n] / total
+						}
+						errs[p] = e
+					}
+				}
+			}
+		}
+	}
+
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				if vol.Filled[x][y][z] {
+					vol.Voxels[x][y][z] = quantized[x][y][z]
+				}
+			}
+		}
+	}
+	vol.Truecolor = nil
+	return nil
+}
+
+// newVolumeIndices allocates palette indices for the voxels within lo-hi of
+// a volume, indexed like Volume.Voxels.
+func newVolumeIndices(maxx, maxy, maxz int, lo, hi [3]int) [][][]uint8 {
+	indices := make([][][]uint8, maxx)
+	for x := lo[0]; x <= hi[0]; x++ {
+		indices[x] = make([][]uint8, maxy)
+		for y := lo[1]; y <= hi[1]; y++ {
+			indices[x][y] = make([]uint8, maxz)
+		}
+	}
+	return indices
+}
+
+// voxelColors returns the colors of all filled voxels, for building a
+// palette that suits the model.
+func (vol *Volume) voxelColors(decode color.Palette) (colors []color.RGBA) {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return
+	}
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				if vol.Filled[x][y][z] {
+					colors = append(colors, vol.ColorAt(x, y, z, decode))
+				}
+			}
+		}
+	}
+	return
+}
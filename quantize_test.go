@@ -0,0 +1,55 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestQuantizeExact(t *testing.T) {
+	decode := testPalette()
+	// the target palette holds the same colors in reverse order:
+	target := make(color.Palette, len(decode))
+	for i := range target {
+		target[i] = decode[255-i]
+	}
+
+	// ordered dithering offsets every surface voxel on purpose; error
+	// diffusion has no error to spread:
+	for _, dither := range []string{"none", "diffuse"} {
+		vol := NewVolume(4, 4, 4)
+		for x := 0; x < 4; x++ {
+			for y := 0; y < 4; y++ {
+				for z := 0; z < 4; z++ {
+					vol.Filled[x][y][z] = true
+					vol.Voxels[x][y][z] = uint8(x*64 + y*16 + z)
+				}
+			}
+		}
+		// truecolors win over the decode palette:
+		vol.SetTruecolor(1, 1, 1, rgbaOf(decode[7]))
+
+		if err := vol.Quantize(decode, target, dither); err != nil {
+			t.Fatal(err)
+		}
+		if vol.Truecolor != nil {
+			t.Errorf("%s: truecolors kept", dither)
+		}
+		for x := 0; x < 4; x++ {
+			for y := 0; y < 4; y++ {
+				for z := 0; z < 4; z++ {
+					want := uint8(255 - (x*64 + y*16 + z))
+					if x == 1 && y == 1 && z == 1 {
+						want = 255 - 7
+					}
+					if got := vol.Voxels[x][y][z]; got != want {
+						t.Errorf("%s: voxel (%d,%d,%d) = %d, want %d", dither, x, y, z, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	if err := NewVolume(1, 1, 1).Quantize(decode, target, "random"); err == nil {
+		t.Error("dither random accepted")
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"awesomeProject/matrix4"
+	"awesomeProject/vector3"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+)
+
+// RenderOptions controls how a Volume is rendered to an image.
+type RenderOptions struct {
+	Width, Height int
+
+	// Yaw rotates the camera around the Z axis, in radians; 0 looks at the
+	// front of the model, the same direction as sprite rotation 1.
+	Yaw float64
+	// Pitch tilts the camera down towards the model, in radians.
+	Pitch float64
+
+	// Perspective switches from orthographic to perspective projection.
+	Perspective bool
+	// FOV is the vertical field of view, in radians, for perspective projection.
+	FOV float64
+	// Scale is the number of pixels per voxel for orthographic projection;
+	// 0 fits the model into the image.
+	Scale float64
+
+	// Light is the direction towards the light source.
+	Light vector3.V
+	// Ambient is the fraction of light that reaches faces turned away from Light.
+	Ambient float64
+
+	Background color.RGBA
+}
+
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Width:      256,
+		Height:     256,
+		Yaw:        math.Pi / 6.0,
+		Pitch:      math.Pi / 12.0,
+		FOV:        math.Pi / 4.0,
+		Light:      vector3.V{X: -0.4, Y: -0.6, Z: 0.7},
+		Ambient:    0.45,
+		Background: color.RGBA{R: 0x30, G: 0x30, B: 0x38, A: 0xFF},
+	}
+}
+
+// camera describes the rays cast for each pixel of a render.
+type camera struct {
+	origin  vector3.V
+	forward vector3.V
+	right   vector3.V
+	up      vector3.V
+
+	perspective bool
+	// pixel size for orthographic, tan(fov/2)/halfHeight for perspective:
+	pixel float64
+
+	// bounding box of the filled voxels; rays are clipped to it:
+	lo, hi [3]int
+
+	halfWidth, halfHeight float64
+}
+
+func newCamera(vol *Volume, opts RenderOptions) (cam camera, ok bool) {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return
+	}
+	cam.lo, cam.hi = lo, hi
+
+	center := vector3.V{
+		X: float64(lo[0]+hi[0]+1) / 2.0,
+		Y: float64(lo[1]+hi[1]+1) / 2.0,
+		Z: float64(lo[2]+hi[2]+1) / 2.0,
+	}
+	extent := vector3.V{
+		X: float64(hi[0] - lo[0] + 1),
+		Y: float64(hi[1] - lo[1] + 1),
+		Z: float64(hi[2] - lo[2] + 1),
+	}
+	radius := math.Sqrt(extent.Dot(extent)) / 2.0
+
+	// rotation 1 looks from -Y towards +Y, same as the carving cameras:
+	rot := matrix4.RotationZ(opts.Yaw).Multiply(matrix4.RotationX(-opts.Pitch))
+	cam.forward = rot.Transform(vector3.V{X: 0, Y: 1, Z: 0})
+	cam.right = rot.Transform(vector3.V{X: 1, Y: 0, Z: 0})
+	cam.up = rot.Transform(vector3.V{X: 0, Y: 0, Z: 1})
+
+	cam.halfWidth = float64(opts.Width) / 2.0
+	cam.halfHeight = float64(opts.Height) / 2.0
+	cam.perspective = opts.Perspective
+
+	if opts.Perspective {
+		fov := opts.FOV
+		if fov <= 0 {
+			fov = math.Pi / 4.0
+		}
+		dist := radius / math.Sin(fov/2.0)
+		cam.origin = center.Subtract(cam.forward.Scale(dist))
+		cam.pixel = math.Tan(fov/2.0) / cam.halfHeight
+	} else {
+		cam.origin = center.Subtract(cam.forward.Scale(radius * 2.0))
+		if opts.Scale > 0 {
+			cam.pixel = 1.0 / opts.Scale
+		} else {
+			cam.pixel = 2.0 * radius / math.Min(float64(opts.Width), float64(opts.Height))
+		}
+	}
+
+	return
+}
+
+// ray returns the origin and direction of the ray through pixel (px, py).
+func (cam *camera) ray(px, py int) (origin, dir vector3.V) {
+	sx := (float64(px) + 0.5 - cam.halfWidth) * cam.pixel
+	sy := (cam.halfHeight - float64(py) - 0.5) * cam.pixel
+
+	if cam.perspective {
+		dir = cam.forward.Add(cam.right.Scale(sx)).Add(cam.up.Scale(sy)).Normalize()
+		return cam.origin, dir
+	}
+
+	origin = cam.origin.Add(cam.right.Scale(sx)).Add(cam.up.Scale(sy))
+	return origin, cam.forward
+}
+
+// traceVoxel walks the box lo..hi (inclusive) along a ray using a 3D DDA and
+// returns the first filled voxel hit and the normal of the face it was entered
+// through.
+func traceVoxel(vol *Volume, lo, hi [3]int, origin, dir vector3.V) (p [3]int, normal vector3.V, hit bool) {
+	o := [3]float64{origin.X, origin.Y, origin.Z}
+	d := [3]float64{dir.X, dir.Y, dir.Z}
+	low := [3]float64{float64(lo[0]), float64(lo[1]), float64(lo[2])}
+	size := [3]float64{float64(hi[0] + 1), float64(hi[1] + 1), float64(hi[2] + 1)}
+
+	// clip the ray against the box:
+	tmin, tmax := 0.0, math.Inf(1)
+	enterAxis := -1
+	for a := 0; a < 3; a++ {
+		if d[a] == 0 {
+			if o[a] < low[a] || o[a] >= size[a] {
+				return
+			}
+			continue
+		}
+		t0 := (low[a] - o[a]) / d[a]
+		t1 := (size[a] - o[a]) / d[a]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tmin {
+			tmin = t0
+			enterAxis = a
+		}
+		if t1 < tmax {
+			tmax = t1
+		}
+	}
+	if tmin > tmax {
+		return
+	}
+
+	var step [3]int
+	var tDelta, tNext [3]float64
+	for a := 0; a < 3; a++ {
+		pos := o[a] + d[a]*tmin
+		p[a] = int(math.Floor(pos))
+		if p[a] < lo[a] {
+			p[a] = lo[a]
+		}
+		if p[a] >= int(size[a]) {
+			p[a] = int(size[a]) - 1
+		}
+
+		switch {
+		case d[a] > 0:
+			step[a] = 1
+			tDelta[a] = 1.0 / d[a]
+			tNext[a] = tmin + (float64(p[a]+1)-pos)/d[a]
+		case d[a] < 0:
+			step[a] = -1
+			tDelta[a] = -1.0 / d[a]
+			tNext[a] = tmin + (float64(p[a])-pos)/d[a]
+		default:
+			tNext[a] = math.Inf(1)
+			tDelta[a] = math.Inf(1)
+		}
+	}
+
+	axis := enterAxis
+	for {
+		if vol.Filled[p[0]][p[1]][p[2]] {
+			hit = true
+			n := [3]float64{}
+			if axis >= 0 {
+				n[axis] = -float64(step[axis])
+			}
+			normal = vector3.V{X: n[0], Y: n[1], Z: n[2]}
+			return
+		}
+
+		// advance to the nearest cell boundary:
+		axis = 0
+		if tNext[1] < tNext[axis] {
+			axis = 1
+		}
+		if tNext[2] < tNext[axis] {
+			axis = 2
+		}
+		if tNext[axis] > tmax {
+			return
+		}
+
+		p[axis] += step[axis]
+		if p[axis] < lo[axis] || p[axis] > hi[axis] {
+			return
+		}
+		tNext[axis] += tDelta[axis]
+	}
+}
+
+// RenderVolume renders the filled voxels of vol with palette colors and simple
+// directional lighting.
+func RenderVolume(vol *Volume, pal color.Palette, opts RenderOptions) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	draw.Draw(img, img.Rect, &image.Uniform{C: opts.Background}, image.Point{}, draw.Src)
+
+	cam, ok := newCamera(vol, opts)
+	if !ok {
+		return img
+	}
+
+	light := opts.Light.Normalize()
+
+	for py := 0; py < opts.Height; py++ {
+		for px := 0; px < opts.Width; px++ {
+			origin, dir := cam.ray(px, py)
+			p, normal, hit := traceVoxel(vol, cam.lo, cam.hi, origin, dir)
+			if !hit {
+				continue
+			}
+
+			r, g, b, _ := pal[vol.Voxels[p[0]][p[1]][p[2]]].RGBA()
+
+			diffuse := normal.Dot(light)
+			if diffuse < 0 {
+				diffuse = 0
+			}
+			k := opts.Ambient + (1.0-opts.Ambient)*diffuse
+
+			img.SetRGBA(px, py, color.RGBA{
+				R: shade(r, k),
+				G: shade(g, k),
+				B: shade(b, k),
+				A: 0xFF,
+			})
+		}
+	}
+
+	return img
+}
+
+func shade(c uint32, k float64) uint8 {
+	v := float64(c>>8) * k
+	if v > 255 {
+		v = 255
+	}
+	return uint8(v)
+}
+
+// RenderTurntable renders a full revolution around the model in the given
+// number of frames, starting at opts.Yaw. delay is in 100ths of a second.
+func RenderTurntable(vol *Volume, pal color.Palette, opts RenderOptions, frames int, delay int) *gif.GIF {
+	anim := &gif.GIF{}
+	start := opts.Yaw
+	nearest := make(map[color.RGBA]uint8)
+
+	for i := 0; i < frames; i++ {
+		opts.Yaw = start + math.Pi*2.0*(float64(i)/float64(frames))
+		img := RenderVolume(vol, pal, opts)
+
+		// map the lit colors back onto the palette:
+		frame := image.NewPaletted(img.Rect, pal)
+		for i := 0; i < len(img.Pix); i += 4 {
+			c := color.RGBA{R: img.Pix[i+0], G: img.Pix[i+1], B: img.Pix[i+2], A: img.Pix[i+3]}
+			idx, ok := nearest[c]
+			if !ok {
+				idx = uint8(pal.Index(c))
+				nearest[c] = idx
+			}
+			frame.Pix[i/4] = idx
+		}
+
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	return anim
+}
+
+func savePNG(path string, img image.Image) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+}
+
+func saveGIF(path string, anim *gif.GIF) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return gif.EncodeAll(w, anim)
+	})
+}
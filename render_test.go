@@ -0,0 +1,69 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestRenderVolume(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{A: 0xFF},
+		color.RGBA{R: 0xFF, A: 0xFF},
+		color.RGBA{G: 0xFF, A: 0xFF},
+		color.RGBA{B: 0xFF, A: 0xFF},
+	}
+	red, green, blue := color.RGBA{R: 0xFF, A: 0xFF}, color.RGBA{G: 0xFF, A: 0xFF}, color.RGBA{B: 0xFF, A: 0xFF}
+	background := color.RGBA{R: 1, G: 2, B: 3, A: 0xFF}
+
+	// a red voxel in front of a green one, seen from -Y, and a blue one up
+	// and to the left:
+	vol := NewVolume(3, 3, 3)
+	for _, v := range []struct {
+		x, y, z int
+		c       uint8
+	}{{1, 0, 1, 1}, {1, 2, 1, 2}, {0, 2, 2, 3}} {
+		vol.Filled[v.x][v.y][v.z] = true
+		vol.Voxels[v.x][v.y][v.z] = v.c
+	}
+
+	// orthographic at 10 pixels per voxel, unshaded; the bounds are
+	// centered on x = 1, z = 2:
+	opts := DefaultRenderOptions()
+	opts.Width, opts.Height = 40, 40
+	opts.Yaw, opts.Pitch = 0, 0
+	opts.Scale = 10
+	opts.Ambient = 1
+	opts.Background = background
+
+	tests := []struct {
+		yaw    float64
+		front  bool
+		px, py int
+		want   color.RGBA
+	}{
+		{0, true, 25, 25, red},
+		{0, true, 15, 15, blue},
+		{0, true, 15, 25, background},
+		{0, true, 25, 15, background},
+		{0, true, 39, 39, background},
+		// the green voxel shows once the red one is gone:
+		{0, false, 25, 25, green},
+		// from behind, the green voxel is in front and left and right swap:
+		{math.Pi, true, 15, 25, green},
+		{math.Pi, true, 25, 15, blue},
+		{math.Pi, true, 25, 25, background},
+	}
+	for _, tt := range tests {
+		vol.Filled[1][0][1] = tt.front
+		opts.Yaw = tt.yaw
+		img := RenderVolume(vol, pal, opts)
+		if got := img.RGBAAt(tt.px, tt.py); got != tt.want {
+			t.Errorf("yaw %g, front voxel %v: pixel %d,%d = %v, want %v", tt.yaw, tt.front, tt.px, tt.py, got, tt.want)
+		}
+	}
+
+	if img := RenderVolume(NewVolume(2, 2, 2), pal, opts); img.RGBAAt(20, 20) != background {
+		t.Error("empty volume rendered something")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"awesomeProject/matrix4"
+	"awesomeProject/vector3"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// spriteRotationChars returns the rotation characters used in sprite lump
+// names, in order of increasing view angle. 16-angle sets interleave the
+// extra rotations 9-G between the original 8, as understood by ZDoom-derived
+// source ports.
+func spriteRotationChars(rotations int) (string, error) {
+	switch rotations {
+	case 8:
+		return "12345678", nil
+	case 16:
+		return "192A3B4C5D6E7F8G", nil
+	}
+	return "", fmt.Errorf("unsupported number of sprite rotations %d; must be 8 or 16", rotations)
+}
+
+// RenderSprite renders vol as seen from the given angle with one pixel per
+// voxel and no lighting, so that every pixel is an original palette index.
+// The returned offsets place vol.Pivot at the sprite's origin.
+func RenderSprite(vol *Volume, yaw float64) (img *image.Paletted, mask *image.Alpha, leftoffs, topoffs int) {
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		img = image.NewPaletted(image.Rect(0, 0, 1, 1), nil)
+		mask = image.NewAlpha(img.Rect)
+		return
+	}
+
+	rot := matrix4.RotationZ(yaw)
+	forward := rot.Transform(vector3.V{X: 0, Y: 1, Z: 0})
+	right := rot.Transform(vector3.V{X: 1, Y: 0, Z: 0})
+
+	// horizontal extent is the furthest bounding box corner from the turning axis:
+	reach := 0.0
+	for _, cx := range []int{lo[0], hi[0] + 1} {
+		for _, cy := range []int{lo[1], hi[1] + 1} {
+			dx := float64(cx) - vol.Pivot.X
+			dy := float64(cy) - vol.Pivot.Y
+			if d := math.Sqrt(dx*dx + dy*dy); d > reach {
+				reach = d
+			}
+		}
+	}
+
+	half := int(math.Ceil(reach))
+	top := float64(hi[2] + 1)
+	width := half * 2
+	height := hi[2] + 1 - lo[2]
+
+	full := image.NewPaletted(image.Rect(0, 0, width, height), nil)
+	fullMask := image.NewAlpha(full.Rect)
+
+	// start rays well outside the model; the pivot sits in the middle of a
+	// voxel column, so column half samples the centers of the voxels on it.
+	// Diagonal rays run exactly along voxel edges, so nudge them off to one
+	// side to not depend on rounding:
+	back := forward.Scale(-(reach + 2))
+	for px := 0; px < width; px++ {
+		sx := float64(px-half) + 1e-3
+		for py := 0; py < height; py++ {
+			sz := top - float64(py) - 0.5
+			origin := vector3.V{X: vol.Pivot.X, Y: vol.Pivot.Y, Z: sz}.
+				Add(right.Scale(sx)).
+				Add(back)
+
+			p, _, hit := traceVoxel(vol, lo, hi, origin, forward)
+			if !hit {
+				continue
+			}
+			full.Pix[full.PixOffset(px, py)] = vol.Voxels[p[0]][p[1]][p[2]]
+			fullMask.SetAlpha(px, py, color.Alpha{A: 0xFF})
+		}
+	}
+
+	// crop to the opaque pixels:
+	crop := image.Rectangle{}
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			if fullMask.AlphaAt(px, py).A != 0 {
+				crop = crop.Union(image.Rect(px, py, px+1, py+1))
+			}
+		}
+	}
+	if crop.Empty() {
+		crop = image.Rect(0, 0, 1, 1)
+	}
+
+	img = image.NewPaletted(image.Rect(0, 0, crop.Dx(), crop.Dy()), nil)
+	mask = image.NewAlpha(img.Rect)
+	for py := 0; py < crop.Dy(); py++ {
+		for px := 0; px < crop.Dx(); px++ {
+			img.SetColorIndex(px, py, full.ColorIndexAt(crop.Min.X+px, crop.Min.Y+py))
+			mask.SetAlpha(px, py, fullMask.AlphaAt(crop.Min.X+px, crop.Min.Y+py))
+		}
+	}
+
+	leftoffs = half - crop.Min.X
+	topoffs = int(math.Round(top-vol.Pivot.Z)) - crop.Min.Y
+	return
+}
+
+// BuildSpriteLumps renders all rotations of a single sprite frame into Doom
+// patches named after the sprite naming convention, e.g. CYBRA1..CYBRA8.
+func BuildSpriteLumps(baseName string, frameCh byte, vol *Volume, pal color.Palette, rotations int) (lumps []Lump, err error) {
+	chars, err := spriteRotationChars(rotations)
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < rotations; i++ {
+		w := math.Pi * 2.0 * (float64(i) / float64(rotations))
+		img, mask, leftoffs, topoffs := RenderSprite(vol, w)
+		img.Palette = pal
+
+		lumps = append(lumps, Lump{
+			Name: fmt.Sprintf("%s%c%c", baseName, frameCh, chars[i]),
+			Data: encodePatch(img, mask, leftoffs, topoffs),
+		})
+	}
+
+	return
+}
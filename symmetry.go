@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// SymmetryOptions controls the bilateral symmetry prior. Doom sprites face -Y
+// in voxel space, so the symmetry plane is perpendicular to X.
+type SymmetryOptions struct {
+	// Mode is "off", "axis" to mirror across the plane through the pivot
+	// (the sprite's facing axis), or "detect" to search for the best plane.
+	Mode string
+	// Search is how far, in voxels, "detect" looks either side of the pivot.
+	Search int
+	// Merge is "union" to fill gaps from the mirrored side or "intersect" to
+	// drop voxels that have no mirrored counterpart.
+	Merge string
+	// Color is "keep" to leave the colors of voxels present on both sides
+	// alone, or "average" to make mirrored pairs the same color.
+	Color string
+}
+
+func DefaultSymmetryOptions() SymmetryOptions {
+	return SymmetryOptions{
+		Mode:   "off",
+		Search: 8,
+		Merge:  "union",
+		Color:  "keep",
+	}
+}
+
+// SymmetryReport describes the plane used and what the merge changed.
+type SymmetryReport struct {
+	// Plane is the X coordinate of the symmetry plane in voxel index space;
+	// it may fall halfway between two voxels.
+	Plane float64
+	// Score is the intersection over union of the volume and its mirror image.
+	Score     float64
+	Added     int
+	Removed   int
+	Recolored int
+}
+
+// mirrorScore returns the intersection over union of the volume and its
+// mirror image across x' = sum - x.
+func (vol *Volume) mirrorScore(sum int, lo, hi [3]int) float64 {
+	var inter, union int
+	for x := lo[0]; x <= hi[0]; x++ {
+		mx := sum - x
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				a := vol.Filled[x][y][z]
+				b := vol.IsFilled(mx, y, z)
+				if a && b {
+					inter++
+				}
+				if a || b {
+					union++
+				}
+			}
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// Symmetrize mirrors the volume across its symmetry plane and merges the two
+// halves according to opts.
+func (vol *Volume) Symmetrize(pal color.Palette, opts SymmetryOptions) (report SymmetryReport, err error) {
+	if opts.Merge != "union" && opts.Merge != "intersect" {
+		err = fmt.Errorf("symmetry merge must be union or intersect, got %q", opts.Merge)
+		return
+	}
+	if opts.Color != "keep" && opts.Color != "average" {
+		err = fmt.Errorf("symmetry color must be keep or average, got %q", opts.Color)
+		return
+	}
+
+	lo, hi, ok := vol.Bounds()
+	if !ok {
+		return
+	}
+
+	// voxel x covers [x, x+1), so the plane through the pivot maps x to
+	// 2*pivot-1-x:
+	sum := int(math.Round(2*vol.Pivot.X)) - 1
+
+	switch opts.Mode {
+	case "off":
+		return
+	case "axis":
+		report.Score = vol.mirrorScore(sum, lo, hi)
+	case "detect":
+		best := sum
+		report.Score = -1
+		for s := sum - 2*opts.Search; s <= sum+2*opts.Search; s++ {
+			if score := vol.mirrorScore(s, lo, hi); score > report.Score {
+				report.Score = score
+				best = s
+			}
+		}
+		sum = best
+	default:
+		err = fmt.Errorf("symmetry mode must be off, axis or detect, got %q", opts.Mode)
+		return
+	}
+	report.Plane = float64(sum) / 2.0
+
+	filled := newMask(vol.MaxX, vol.MaxY, vol.MaxZ)
+	voxels := make([][][]uint8, vol.MaxX)
+	for x := 0; x < vol.MaxX; x++ {
+		voxels[x] = make([][]uint8, vol.MaxY)
+		for y := 0; y < vol.MaxY; y++ {
+			voxels[x][y] = append([]uint8(nil), vol.Voxels[x][y]...)
+			copy(filled[x][y], vol.Filled[x][y])
+		}
+	}
+	var truecolor map[[3]int]color.RGBA
+	if vol.Truecolor != nil {
+		truecolor = make(map[[3]int]color.RGBA, len(vol.Truecolor))
+		for p, c := range vol.Truecolor {
+			truecolor[p] = c
+		}
+	}
+
+	// mirrored voxels may land anywhere along X:
+	for x := 0; x < vol.MaxX; x++ {
+		mx := sum - x
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				a := filled[x][y][z]
+				b := vol.Contains(mx, y, z) && filled[mx][y][z]
+
+				switch {
+				case a && b:
+					if opts.Color == "average" && truecolor != nil {
+						// truecolor voxels keep the exact average:
+						ca, oka := truecolor[[3]int{x, y, z}]
+						cb, okb := truecolor[[3]int{mx, y, z}]
+						if !oka {
+							ca = rgbaOf(pal[voxels[x][y][z]])
+						}
+						if !okb {
+							cb = rgbaOf(pal[voxels[mx][y][z]])
+						}
+						if ca != cb {
+							c := color.RGBA{
+								R: uint8((int(ca.R) + int(cb.R) + 1) / 2),
+								G: uint8((int(ca.G) + int(cb.G) + 1) / 2),
+								B: uint8((int(ca.B) + int(cb.B) + 1) / 2),
+								A: 0xFF,
+							}
+							vol.SetTruecolor(x, y, z, c)
+							vol.Voxels[x][y][z] = uint8(pal.Index(c))
+							report.Recolored++
+						}
+					} else if opts.Color == "average" {
+						c := averagePair(pal, voxels[x][y][z], voxels[mx][y][z])
+						if c != vol.Voxels[x][y][z] {
+							vol.Voxels[x][y][z] = c
+							report.Recolored++
+						}
+					}
+				case b && opts.Merge == "union":
+					vol.Filled[x][y][z] = true
+					vol.Voxels[x][y][z] = voxels[mx][y][z]
+					if c, ok := truecolor[[3]int{mx, y, z}]; ok {
+						vol.SetTruecolor(x, y, z, c)
+					}
+					report.Added++
+				case a && opts.Merge == "intersect":
+					vol.Filled[x][y][z] = false
+					report.Removed++
+				}
+			}
+		}
+	}
+
+	return
+}
+
+func averagePair(pal color.Palette, a, b uint8) uint8 {
+	if a == b {
+		return a
+	}
+	ar, ag, ab, _ := pal[a].RGBA()
+	br, bg, bb, _ := pal[b].RGBA()
+	return uint8(pal.Index(color.RGBA{
+		R: uint8((ar>>8 + br>>8 + 1) / 2),
+		G: uint8((ag>>8 + bg>>8 + 1) / 2),
+		B: uint8((ab>>8 + bb>>8 + 1) / 2),
+		A: 0xFF,
+	}))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSymmetrize(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{A: 0xFF},
+		color.RGBA{R: 200, A: 0xFF},
+		color.RGBA{B: 200, A: 0xFF},
+		color.RGBA{G: 200, A: 0xFF},
+		color.RGBA{R: 100, B: 100, A: 0xFF},
+	}
+	// the plane through the pivot maps x to 7-x; (2,1,1) and (5,1,1) are a
+	// pair in different colors, (1,2,2) has no counterpart:
+	newVol := func() *Volume {
+		vol := NewVolume(8, 4, 4)
+		vol.Pivot.X = 4
+		set := func(x, y, z int, c uint8) {
+			vol.Filled[x][y][z] = true
+			vol.Voxels[x][y][z] = c
+		}
+		set(2, 1, 1, 1)
+		set(5, 1, 1, 2)
+		set(1, 2, 2, 3)
+		return vol
+	}
+
+	tests := []struct {
+		name   string
+		opts   SymmetryOptions
+		report SymmetryReport
+		count  int
+		mirror bool
+		lone   bool
+		left   uint8
+		right  uint8
+	}{
+		{"off", SymmetryOptions{Mode: "off", Merge: "union", Color: "keep"},
+			SymmetryReport{}, 3, false, true, 1, 2},
+		{"union", SymmetryOptions{Mode: "axis", Merge: "union", Color: "keep"},
+			SymmetryReport{Plane: 3.5, Score: 2.0 / 3.0, Added: 1}, 4, true, true, 1, 2},
+		{"intersect", SymmetryOptions{Mode: "axis", Merge: "intersect", Color: "keep"},
+			SymmetryReport{Plane: 3.5, Score: 2.0 / 3.0, Removed: 1}, 2, false, false, 1, 2},
+		{"average", SymmetryOptions{Mode: "axis", Merge: "union", Color: "average"},
+			SymmetryReport{Plane: 3.5, Score: 2.0 / 3.0, Added: 1, Recolored: 2}, 4, true, true, 4, 4},
+	}
+	for _, tt := range tests {
+		vol := newVol()
+		report, err := vol.Symmetrize(pal, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report != tt.report {
+			t.Errorf("%s: report %+v, want %+v", tt.name, report, tt.report)
+		}
+		if n := vol.Count(); n != tt.count {
+			t.Errorf("%s: %d voxels, want %d", tt.name, n, tt.count)
+		}
+		if vol.Filled[6][2][2] != tt.mirror {
+			t.Errorf("%s: mirrored voxel = %v, want %v", tt.name, vol.Filled[6][2][2], tt.mirror)
+		} else if tt.mirror && vol.Voxels[6][2][2] != 3 {
+			t.Errorf("%s: mirrored voxel has color %d, want 3", tt.name, vol.Voxels[6][2][2])
+		}
+		if vol.Filled[1][2][2] != tt.lone {
+			t.Errorf("%s: unpaired voxel = %v, want %v", tt.name, vol.Filled[1][2][2], tt.lone)
+		}
+		if vol.Voxels[2][1][1] != tt.left || vol.Voxels[5][1][1] != tt.right {
+			t.Errorf("%s: pair colors %d, %d; want %d, %d", tt.name, vol.Voxels[2][1][1], vol.Voxels[5][1][1], tt.left, tt.right)
+		}
+	}
+
+	// detect finds a plane away from the pivot when the shape is symmetric
+	// about it:
+	vol := NewVolume(8, 4, 4)
+	vol.Pivot.X = 4
+	vol.Filled[2][1][1], vol.Filled[3][1][1] = true, true
+	vol.Filled[1][2][2], vol.Filled[4][2][2] = true, true
+	report, err := vol.Symmetrize(pal, SymmetryOptions{Mode: "detect", Search: 4, Merge: "union", Color: "keep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Plane != 2.5 || report.Score != 1 || report.Added != 0 {
+		t.Errorf("detect: report %+v, want plane 2.5, score 1 and nothing added", report)
+	}
+
+	if _, err := newVol().Symmetrize(pal, SymmetryOptions{Mode: "axis", Merge: "xor", Color: "keep"}); err == nil {
+		t.Error("merge xor accepted")
+	}
+}
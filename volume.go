@@ -0,0 +1,188 @@
+package main
+
+import (
+	"awesomeProject/vector3"
+	"image/color"
+)
+
+// Volume is a dense voxel grid indexed as [x][y][z]:
+// X - (width)
+// Y / (depth)
+// Z | (height)
+type Volume struct {
+	MaxX, MaxY, MaxZ int
+
+	// Voxels holds the palette index of each voxel:
+	Voxels [][][]uint8
+	// Filled marks which voxels are solid:
+	Filled [][][]bool
+	// Emissive marks voxels that glow, such as fullbright sprite pixels;
+	// it is nil until the first one is marked.
+	Emissive [][][]bool
+	// Translation marks the palette indices the engine recolors at runtime,
+	// such as the green player range; exporters keep their voxels apart.
+	Translation [256]bool
+	// Truecolor holds the RGBA colors of voxels colored in truecolor, which
+	// exporters quantize to their palette; nil unless the truecolor pipeline
+	// is used. Voxels still holds the nearest palette index of each.
+	Truecolor map[[3]int]color.RGBA
+
+	// Pivot is the actor's origin in voxel coordinates: X and Y sit on the
+	// axis the sprite rotations turn around and Z is at ground level.
+	Pivot vector3.V
+}
+
+func NewVolume(maxx, maxy, maxz int) *Volume {
+	vol := &Volume{
+		MaxX: maxx,
+		MaxY: maxy,
+		MaxZ: maxz,
+		Pivot: vector3.V{
+			X: float64(maxx) / 2.0,
+			Y: float64(maxy) / 2.0,
+			Z: 0,
+		},
+	}
+
+	vol.Voxels = make([][][]uint8, maxx)
+	for i := 0; i < maxx; i++ {
+		vol.Voxels[i] = make([][]uint8, maxy)
+		for j := 0; j < maxy; j++ {
+			vol.Voxels[i][j] = make([]uint8, maxz)
+		}
+	}
+
+	vol.Filled = newMask(maxx, maxy, maxz)
+
+	return vol
+}
+
+func newMask(maxx, maxy, maxz int) [][][]bool {
+	mask := make([][][]bool, maxx)
+	for i := 0; i < maxx; i++ {
+		mask[i] = make([][]bool, maxy)
+		for j := 0; j < maxy; j++ {
+			mask[i][j] = make([]bool, maxz)
+		}
+	}
+	return mask
+}
+
+// Contains reports whether (x, y, z) lies inside the grid.
+func (vol *Volume) Contains(x, y, z int) bool {
+	return x >= 0 && x < vol.MaxX &&
+		y >= 0 && y < vol.MaxY &&
+		z >= 0 && z < vol.MaxZ
+}
+
+// IsFilled is a bounds-checked lookup; anything outside the grid is empty.
+func (vol *Volume) IsFilled(x, y, z int) bool {
+	if !vol.Contains(x, y, z) {
+		return false
+	}
+	return vol.Filled[x][y][z]
+}
+
+// Bounds returns the inclusive bounding box of all filled voxels.
+func (vol *Volume) Bounds() (lo, hi [3]int, ok bool) {
+	lo = [3]int{vol.MaxX, vol.MaxY, vol.MaxZ}
+	hi = [3]int{-1, -1, -1}
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if !vol.Filled[x][y][z] {
+					continue
+				}
+				p := [3]int{x, y, z}
+				for a := 0; a < 3; a++ {
+					if p[a] < lo[a] {
+						lo[a] = p[a]
+					}
+					if p[a] > hi[a] {
+						hi[a] = p[a]
+					}
+				}
+				ok = true
+			}
+		}
+	}
+	return
+}
+
+// Count returns the number of filled voxels.
+func (vol *Volume) Count() (n int) {
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if vol.Filled[x][y][z] {
+					n++
+				}
+			}
+		}
+	}
+	return
+}
+
+// Clear empties the grid and resets all colors to 0.
+func (vol *Volume) Clear() {
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				vol.Filled[x][y][z] = false
+				vol.Voxels[x][y][z] = 0
+			}
+		}
+	}
+	vol.Emissive = nil
+	vol.Truecolor = nil
+}
+
+// SetTruecolor gives the voxel at (x, y, z) an RGBA color.
+func (vol *Volume) SetTruecolor(x, y, z int, c color.RGBA) {
+	if vol.Truecolor == nil {
+		vol.Truecolor = make(map[[3]int]color.RGBA)
+	}
+	vol.Truecolor[[3]int{x, y, z}] = c
+}
+
+// ColorAt returns the color of the voxel at (x, y, z): its truecolor if it
+// has one, otherwise its palette entry in pal.
+func (vol *Volume) ColorAt(x, y, z int, pal color.Palette) color.RGBA {
+	if c, ok := vol.Truecolor[[3]int{x, y, z}]; ok {
+		return c
+	}
+	return rgbaOf(pal[vol.Voxels[x][y][z]])
+}
+
+// MarkEmissive makes the voxel at (x, y, z) glow.
+func (vol *Volume) MarkEmissive(x, y, z int) {
+	if vol.Emissive == nil {
+		vol.Emissive = newMask(vol.MaxX, vol.MaxY, vol.MaxZ)
+	}
+	vol.Emissive[x][y][z] = true
+}
+
+// IsEmissive reports whether the voxel at (x, y, z) is filled and glows.
+func (vol *Volume) IsEmissive(x, y, z int) bool {
+	return vol.Emissive != nil && vol.IsFilled(x, y, z) && vol.Emissive[x][y][z]
+}
+
+// IsTranslatable reports whether the voxel at (x, y, z) is filled with a
+// color of the translation range.
+func (vol *Volume) IsTranslatable(x, y, z int) bool {
+	return vol.IsFilled(x, y, z) && vol.Translation[vol.Voxels[x][y][z]]
+}
+
+// MarkEmissiveColors makes every filled voxel whose palette index is set in
+// bright glow.
+func (vol *Volume) MarkEmissiveColors(bright [256]bool) {
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if vol.Filled[x][y][z] && bright[vol.Voxels[x][y][z]] {
+					vol.MarkEmissive(x, y, z)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// voxMaxModelSize is the largest model dimension MagicaVoxel accepts.
+const voxMaxModelSize = 256
+
+// voxColors maps Doom palette indices onto the 255 usable VOX color indices.
+type voxColors struct {
+	// Slot holds the VOX color index (1-255) for each Doom palette index:
+	Slot [256]uint8
+	// Palette holds the RGBA color of each VOX color index; entry 0 is unused:
+	Palette [256]color.RGBA
+
+	// Glow holds the VOX color index for glowing voxels of each Doom palette
+	// index, and Emissive marks the VOX color indices with emissive materials:
+	Glow     [256]uint8
+	Emissive [256]bool
+}
+
+func rgbaOf(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
+
+// mapVoxColors lays the Doom palette out as index+1 so the file stays familiar
+// to edit, which leaves no room for index 255. That one is stored in the slot
+// of a duplicate color or an index the model does not use; if neither exists
+// the colors cannot be represented.
+func mapVoxColors(pal color.Palette, used [256]bool) (vc voxColors, err error) {
+	for i := 0; i < 255; i++ {
+		vc.Slot[i] = uint8(i + 1)
+		vc.Palette[i+1] = rgbaOf(pal[i])
+	}
+
+	last := rgbaOf(pal[255])
+	if !used[255] {
+		vc.Slot[255] = vc.Slot[0]
+		return
+	}
+
+	// an existing entry with the same color:
+	for i := 0; i < 255; i++ {
+		if vc.Palette[vc.Slot[i]] == last {
+			vc.Slot[255] = vc.Slot[i]
+			return
+		}
+	}
+
+	// an entry the model does not use:
+	for i := 254; i >= 0; i-- {
+		if !used[i] {
+			vc.Slot[255] = vc.Slot[i]
+			vc.Palette[vc.Slot[i]] = last
+			return
+		}
+	}
+
+	// two used entries sharing one color can share one slot:
+	for i := 0; i < 255; i++ {
+		for j := i + 1; j < 255; j++ {
+			if vc.Palette[vc.Slot[i]] == vc.Palette[vc.Slot[j]] {
+				free := vc.Slot[j]
+				vc.Slot[j] = vc.Slot[i]
+				vc.Slot[255] = free
+				vc.Palette[free] = last
+				return
+			}
+		}
+	}
+
+	err = fmt.Errorf("model uses all 256 palette colors and all are distinct; VOX files can only hold 255 colors")
+	return
+}
+
+// mapEmissive assigns VOX color indices to glowing voxels. VOX materials
+// belong to palette entries, so a color used by both glowing and plain voxels
+// needs a second entry; it takes one no voxel uses, and when none is left
+// the plain voxels of that color glow as well.
+func (vc *voxColors) mapEmissive(plain, glowing [256]bool) {
+	var taken, plainSlot [256]bool
+	for c := 0; c < 256; c++ {
+		if plain[c] || glowing[c] {
+			taken[vc.Slot[c]] = true
+		}
+		if plain[c] {
+			plainSlot[vc.Slot[c]] = true
+		}
+	}
+
+	for c := 0; c < 256; c++ {
+		if !glowing[c] {
+			continue
+		}
+		s := vc.Slot[c]
+		vc.Glow[c] = s
+		if plainSlot[s] {
+			for free := 255; free >= 1; free-- {
+				if !taken[free] {
+					taken[free] = true
+					vc.Palette[free] = vc.Palette[s]
+					vc.Glow[c] = uint8(free)
+					break
+				}
+			}
+		}
+		vc.Emissive[vc.Glow[c]] = true
+	}
+}
+
+// voxModel is one model of a VOX scene, covering part of the volume.
+type voxModel struct {
+	// Offset of the model's first voxel in the volume:
+	Offset [3]int
+	Size   [3]int
+	// XYZI data; the color bytes hold Doom palette indices until written:
+	Voxels []byte
+	// Emissive marks the glowing voxels, one per XYZI entry; nil if none do:
+	Emissive []bool
+	// Layer is voxLayerModel, or voxLayerTranslation for the voxels of the
+	// translation range:
+	Layer int
+}
+
+// VOX layers of the exported scene:
+const (
+	voxLayerModel = iota
+	voxLayerTranslation
+)
+
+// voxLayerNames are the names shown for the layers in MagicaVoxel; ToVolume
+// recognizes the translation layer by its name.
+var voxLayerNames = []string{"model", "translation"}
+
+// voxPart identifies the models that stand for the same part of the volume
+// in each animation frame.
+type voxPart struct {
+	Offset [3]int
+	Layer  int
+}
+
+// voxFrame is one animation frame of a VOX scene.
+type voxFrame struct {
+	Models []voxModel
+	// Origin is the voxel coordinate placed at the scene origin:
+	Origin [3]int
+}
+
+// newVoxFrame captures the volume as a VOX animation frame. The frame keeps
+// only the filled voxels, so many of them can be held at once.
+func newVoxFrame(vol *Volume) voxFrame {
+	return voxFrame{
+		Models: splitVoxModels(vol),
+		Origin: voxOrigin(vol),
+	}
+}
+
+// voxOrigin is the voxel coordinate that ends up at the scene origin of an
+// exported model: the actor's pivot, at ground level.
+func voxOrigin(vol *Volume) [3]int {
+	return [3]int{
+		int(math.Floor(vol.Pivot.X)),
+		int(math.Floor(vol.Pivot.Y)),
+		int(math.Floor(vol.Pivot.Z)),
+	}
+}
+
+// splitVoxModels cuts the volume into models no larger than
+// voxMaxModelSize on any side, skipping empty ones. Voxels of the translation
+// range go into models of their own on voxLayerTranslation.
+func splitVoxModels(vol *Volume) (models []voxModel) {
+	for ox := 0; ox < vol.MaxX; ox += voxMaxModelSize {
+		for oy := 0; oy < vol.MaxY; oy += voxMaxModelSize {
+			for oz := 0; oz < vol.MaxZ; oz += voxMaxModelSize {
+				var layers [2]voxModel
+				for l := range layers {
+					m := &layers[l]
+					m.Offset = [3]int{ox, oy, oz}
+					m.Layer = l
+					dims := [3]int{vol.MaxX, vol.MaxY, vol.MaxZ}
+					for a := 0; a < 3; a++ {
+						m.Size[a] = dims[a] - m.Offset[a]
+						if m.Size[a] > voxMaxModelSize {
+							m.Size[a] = voxMaxModelSize
+						}
+					}
+				}
+
+				for x := 0; x < layers[0].Size[0]; x++ {
+					for y := 0; y < layers[0].Size[1]; y++ {
+						for z := 0; z < layers[0].Size[2]; z++ {
+							if vol.Filled[ox+x][oy+y][oz+z] {
+								m := &layers[voxLayerModel]
+								if vol.IsTranslatable(ox+x, oy+y, oz+z) {
+									m = &layers[voxLayerTranslation]
+								}
+								c := vol.Voxels[ox+x][oy+y][oz+z]
+								if vol.IsEmissive(ox+x, oy+y, oz+z) && m.Emissive == nil {
+									m.Emissive = make([]bool, len(m.Voxels)/4)
+								}
+								if m.Emissive != nil {
+									m.Emissive = append(m.Emissive, vol.IsEmissive(ox+x, oy+y, oz+z))
+								}
+								m.Voxels = append(m.Voxels, byte(x), byte(y), byte(z), c)
+							}
+						}
+					}
+				}
+
+				for _, m := range layers {
+					if len(m.Voxels) > 0 {
+						models = append(models, m)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// voxDict is a VOX DICT: a list of key/value string pairs.
+type voxDict [][2]string
+
+func writeVoxString(b *bytes.Buffer, s string) {
+	_ = binary.Write(b, binary.LittleEndian, int32(len(s)))
+	b.WriteString(s)
+}
+
+func writeVoxDict(b *bytes.Buffer, d voxDict) {
+	_ = binary.Write(b, binary.LittleEndian, int32(len(d)))
+	for _, kv := range d {
+		writeVoxString(b, kv[0])
+		writeVoxString(b, kv[1])
+	}
+}
+
+// writeVoxChunk writes a chunk with the given content and no children.
+func writeVoxChunk(file io.Writer, id string, content []byte) {
+	writeVoxChunkHeader(file, id, len(content), 0)
+	_, _ = file.Write(content)
+}
+
+func writeVoxChunkHeader(file io.Writer, id string, size, childrenSize int) {
+	_, _ = io.WriteString(file, id)
+	_ = binary.Write(file, binary.LittleEndian, uint32(size))
+	_ = binary.Write(file, binary.LittleEndian, uint32(childrenSize))
+}
+
+func saveVoxel(voxPath string, vol *Volume, pal color.Palette) error {
+	return saveVoxelFrames(voxPath, []voxFrame{newVoxFrame(vol)}, pal)
+}
+
+func saveVoxelFrames(voxPath string, frames []voxFrame, pal color.Palette) error {
+	err := writeFileAtomic(voxPath, func(w io.Writer) error {
+		return writeVox(w, frames, pal)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", voxPath, err)
+	}
+	return nil
+}
+
+// writeVox writes a VOX file holding one model per animation frame; models
+// larger than voxMaxModelSize are split into parts that each get their own
+// transform node, keyframed with the part's placement in every frame. The
+// translation range gets parts of its own on a separate layer. Chunk
+// sizes are worked out up front so the file is written in a single pass.
+func writeVox(w io.Writer, frames []voxFrame, pal color.Palette) (err error) {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to save")
+	}
+
+	var used, plain, glowing [256]bool
+	for _, fr := range frames {
+		for _, m := range fr.Models {
+			for i := 3; i < len(m.Voxels); i += 4 {
+				c := m.Voxels[i]
+				used[c] = true
+				if m.Emissive != nil && m.Emissive[i/4] {
+					glowing[c] = true
+				} else {
+					plain[c] = true
+				}
+			}
+		}
+	}
+
+	vc, err := mapVoxColors(pal, used)
+	if err != nil {
+		return
+	}
+	vc.mapEmissive(plain, glowing)
+
+	// parts are keyed by their offset in the volume and their layer; a
+	// frame that has nothing in a part shows the empty model there:
+	var parts []voxPart
+	partIndex := map[voxPart]int{}
+	translated := false
+	for _, fr := range frames {
+		for _, m := range fr.Models {
+			part := voxPart{Offset: m.Offset, Layer: m.Layer}
+			if _, ok := partIndex[part]; !ok {
+				partIndex[part] = len(parts)
+				parts = append(parts, part)
+			}
+			if m.Layer == voxLayerTranslation {
+				translated = true
+			}
+		}
+	}
+	if len(parts) == 0 {
+		// MagicaVoxel needs at least one model:
+		parts = append(parts, voxPart{})
+	}
+
+	// model ids of each part in each frame, with -1 for the empty model:
+	var models []voxModel
+	needEmpty := false
+	partModels := make([][]int, len(parts))
+	partFrames := make([][]voxDict, len(parts))
+	for f, fr := range frames {
+		present := make([]bool, len(parts))
+		for _, m := range fr.Models {
+			p := partIndex[voxPart{Offset: m.Offset, Layer: m.Layer}]
+			present[p] = true
+			partModels[p] = append(partModels[p], len(models))
+
+			// MagicaVoxel positions a model by its center:
+			var t [3]int
+			for a := 0; a < 3; a++ {
+				t[a] = m.Offset[a] + m.Size[a]/2 - fr.Origin[a]
+			}
+			partFrames[p] = append(partFrames[p], voxFrameDict(f, len(frames), voxDict{
+				{"_t", fmt.Sprintf("%d %d %d", t[0], t[1], t[2])},
+			}))
+			models = append(models, m)
+		}
+
+		for p := range parts {
+			if present[p] {
+				continue
+			}
+			needEmpty = true
+			partModels[p] = append(partModels[p], -1)
+			partFrames[p] = append(partFrames[p], voxFrameDict(f, len(frames), voxDict{}))
+		}
+	}
+	emptyModel := len(models)
+	if needEmpty {
+		models = append(models, voxModel{Size: [3]int{1, 1, 1}})
+	}
+
+	// The scene graph places each part relative to the pivot:
+	// nTRN 0 -> nGRP 1 -> (nTRN -> nSHP) per part
+	scene := &bytes.Buffer{}
+	writeVoxTransform(scene, 0, 1, -1, []voxDict{{}})
+
+	content := &bytes.Buffer{}
+	_ = binary.Write(content, binary.LittleEndian, int32(1))
+	writeVoxDict(content, voxDict{})
+	_ = binary.Write(content, binary.LittleEndian, int32(len(parts)))
+	for i := range parts {
+		_ = binary.Write(content, binary.LittleEndian, int32(2+i*2))
+	}
+	writeVoxChunk(scene, "nGRP", content.Bytes())
+
+	for p := range parts {
+		ids := partModels[p]
+		attrs := make([]voxDict, len(ids))
+		for f, id := range ids {
+			if id < 0 {
+				ids[f] = emptyModel
+			}
+			attrs[f] = voxFrameDict(f, len(frames), voxDict{})
+		}
+		writeVoxTransform(scene, 2+p*2, 3+p*2, parts[p].Layer, partFrames[p])
+		writeVoxShape(scene, 3+p*2, ids, attrs)
+	}
+
+	// name the layers so the translation range is easy to find and edit:
+	if translated {
+		for l, name := range voxLayerNames {
+			content := &bytes.Buffer{}
+			_ = binary.Write(content, binary.LittleEndian, int32(l))
+			writeVoxDict(content, voxDict{{"_name", name}})
+			// reserved id:
+			_ = binary.Write(content, binary.LittleEndian, int32(-1))
+			writeVoxChunk(scene, "LAYR", content.Bytes())
+		}
+	}
+
+	// palette:
+	palette := &bytes.Buffer{}
+	for i := 1; i < 256; i++ {
+		c := vc.Palette[i]
+		palette.Write([]byte{c.R, c.G, c.B, 0xFF})
+	}
+	palette.Write([]byte{0, 0, 0, 0})
+
+	// emissive materials:
+	materials := &bytes.Buffer{}
+	for i := 1; i < 256; i++ {
+		if !vc.Emissive[i] {
+			continue
+		}
+		content := &bytes.Buffer{}
+		_ = binary.Write(content, binary.LittleEndian, int32(i))
+		writeVoxDict(content, voxDict{{"_type", "_emit"}, {"_emit", "1"}, {"_flux", "1"}})
+		writeVoxChunk(materials, "MATL", content.Bytes())
+	}
+
+	// MAIN holds the models, the scene graph, the palette and the materials:
+	mainSize := scene.Len() + 12 + palette.Len() + materials.Len()
+	for _, m := range models {
+		mainSize += 12 + 12 + 12 + 4 + len(m.Voxels)
+	}
+
+	file := &errWriter{w: w}
+
+	// Write VOX header with version
+	_, _ = io.WriteString(file, "VOX ")
+	_ = binary.Write(file, binary.LittleEndian, uint32(150))
+	writeVoxChunkHeader(file, "MAIN", 0, mainSize)
+
+	xyzi := make([]byte, 0, 4*1024)
+	for _, m := range models {
+		// Write SIZE chunk to describe the voxel dimensions
+		writeVoxChunkHeader(file, "SIZE", 12, 0)
+		for a := 0; a < 3; a++ {
+			_ = binary.Write(file, binary.LittleEndian, uint32(m.Size[a]))
+		}
+
+		// Write XYZI voxel data for indexed-color voxels at X,Y,Z locations
+		writeVoxChunkHeader(file, "XYZI", 4+len(m.Voxels), 0)
+		_ = binary.Write(file, binary.LittleEndian, uint32(len(m.Voxels)/4))
+		for i := 0; i < len(m.Voxels); i += 4 {
+			slot := vc.Slot[m.Voxels[i+3]]
+			if m.Emissive != nil && m.Emissive[i/4] {
+				slot = vc.Glow[m.Voxels[i+3]]
+			}
+			xyzi = append(xyzi, m.Voxels[i], m.Voxels[i+1], m.Voxels[i+2], slot)
+			if len(xyzi) == cap(xyzi) {
+				_, _ = file.Write(xyzi)
+				xyzi = xyzi[:0]
+			}
+		}
+		_, _ = file.Write(xyzi)
+		xyzi = xyzi[:0]
+	}
+
+	_, _ = file.Write(scene.Bytes())
+	writeVoxChunk(file, "RGBA", palette.Bytes())
+	_, _ = file.Write(materials.Bytes())
+
+	return file.err
+}
+
+// voxFrameDict tags a node attribute dictionary with its animation frame;
+// still models are written without frame numbers.
+func voxFrameDict(frame, frames int, d voxDict) voxDict {
+	if frames <= 1 {
+		return d
+	}
+	return append(voxDict{{"_f", strconv.Itoa(frame)}}, d...)
+}
+
+// writeVoxTransform writes an nTRN node with the given keyframes.
+func writeVoxTransform(file io.Writer, id, child, layer int, frames []voxDict) {
+	content := &bytes.Buffer{}
+	_ = binary.Write(content, binary.LittleEndian, int32(id))
+	writeVoxDict(content, voxDict{})
+	_ = binary.Write(content, binary.LittleEndian, int32(child))
+	// reserved id:
+	_ = binary.Write(content, binary.LittleEndian, int32(-1))
+	_ = binary.Write(content, binary.LittleEndian, int32(layer))
+	_ = binary.Write(content, binary.LittleEndian, int32(len(frames)))
+	for _, frame := range frames {
+		writeVoxDict(content, frame)
+	}
+	writeVoxChunk(file, "nTRN", content.Bytes())
+}
+
+// writeVoxShape writes an nSHP node referencing the given models.
+func writeVoxShape(file io.Writer, id int, models []int, attrs []voxDict) {
+	content := &bytes.Buffer{}
+	_ = binary.Write(content, binary.LittleEndian, int32(id))
+	writeVoxDict(content, voxDict{})
+	_ = binary.Write(content, binary.LittleEndian, int32(len(models)))
+	for i, m := range models {
+		_ = binary.Write(content, binary.LittleEndian, int32(m))
+		if i < len(attrs) {
+			writeVoxDict(content, attrs[i])
+		} else {
+			writeVoxDict(content, voxDict{})
+		}
+	}
+	writeVoxChunk(file, "nSHP", content.Bytes())
+}
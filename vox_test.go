@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// testPalette returns 256 distinct colors.
+func testPalette() color.Palette {
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{R: uint8(i), G: uint8(255 - i), B: uint8(i * 7), A: 255}
+	}
+	return pal
+}
+
+// testVolume returns a volume with a plain, a glowing, a translated and a
+// palette index 255 voxel around the pivot, the latter ones moved by shift.
+func testVolume(shift int) *Volume {
+	vol := NewVolume(7, 9, 6)
+	vol.Pivot.X, vol.Pivot.Y = 3.5, 4.5
+	set := func(x, y, z int, c uint8) {
+		vol.Filled[x][y][z] = true
+		vol.Voxels[x][y][z] = c
+	}
+	set(3, 4, 0, 10)
+	set(3, 4, 1, 10)
+	set(0, 8, 5, 255)
+	set(6, 0, 2+shift, 40)
+	vol.MarkEmissive(6, 0, 2+shift)
+	// the same color glowing and plain:
+	set(5, 5, 3, 40)
+	set(1, 2, 3+shift, 112)
+	vol.Translation[112] = true
+	return vol
+}
+
+func TestVoxRoundTrip(t *testing.T) {
+	pal := testPalette()
+	frames := []*Volume{testVolume(0), testVolume(1)}
+
+	var b bytes.Buffer
+	if err := writeVox(&b, []voxFrame{newVoxFrame(frames[0]), newVoxFrame(frames[1])}, pal); err != nil {
+		t.Fatal(err)
+	}
+	vf, err := ParseVox(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for frame, want := range frames {
+		got, err := vf.ToVolume(pal, frame)
+		if err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
+		}
+		if got.Count() != want.Count() {
+			t.Errorf("frame %d: %d voxels, want %d", frame, got.Count(), want.Count())
+		}
+		if got.Truecolor != nil {
+			t.Errorf("frame %d: %d truecolor voxels", frame, len(got.Truecolor))
+		}
+		if got.Translation != want.Translation {
+			t.Errorf("frame %d: translation range differs", frame)
+		}
+
+		// compare relative to the pivot columns:
+		var shift [3]int
+		for a, p := range [][2]float64{{want.Pivot.X, got.Pivot.X}, {want.Pivot.Y, got.Pivot.Y}, {want.Pivot.Z, got.Pivot.Z}} {
+			shift[a] = int(math.Floor(p[1])) - int(math.Floor(p[0]))
+		}
+		for x := 0; x < want.MaxX; x++ {
+			for y := 0; y < want.MaxY; y++ {
+				for z := 0; z < want.MaxZ; z++ {
+					if !want.Filled[x][y][z] {
+						continue
+					}
+					gx, gy, gz := x+shift[0], y+shift[1], z+shift[2]
+					if !got.IsFilled(gx, gy, gz) {
+						t.Errorf("frame %d: voxel %d,%d,%d is missing", frame, x, y, z)
+						continue
+					}
+					if got.Voxels[gx][gy][gz] != want.Voxels[x][y][z] {
+						t.Errorf("frame %d: voxel %d,%d,%d has color %d, want %d", frame, x, y, z, got.Voxels[gx][gy][gz], want.Voxels[x][y][z])
+					}
+					if got.IsEmissive(gx, gy, gz) != want.IsEmissive(x, y, z) {
+						t.Errorf("frame %d: voxel %d,%d,%d emissive = %v", frame, x, y, z, got.IsEmissive(gx, gy, gz))
+					}
+				}
+			}
+		}
+	}
+
+	if _, err := vf.ToVolume(pal, 2); err != nil {
+		t.Errorf("frame past the end: %v", err)
+	}
+}
+
+func TestParseVoxErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		[]byte("VOX "),
+		[]byte("RIFF\x96\x00\x00\x00MAIN"),
+		// a chunk running past the end of the file:
+		[]byte("VOX \x96\x00\x00\x00MAIN\x00\x00\x00\x00\x10\x00\x00\x00SIZE\x0c\x00\x00\x00\x00\x00\x00\x00"),
+		// a SIZE chunk too short for its fields:
+		[]byte("VOX \x96\x00\x00\x00MAIN\x00\x00\x00\x00\x10\x00\x00\x00SIZE\x04\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00"),
+		// voxels without a size:
+		[]byte("VOX \x96\x00\x00\x00MAIN\x00\x00\x00\x00\x10\x00\x00\x00XYZI\x04\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"),
+	} {
+		if _, err := ParseVox(b); err == nil {
+			t.Errorf("%q: no error", b)
+		}
+	}
+}
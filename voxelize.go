@@ -0,0 +1,501 @@
+package main
+
+import (
+	"awesomeProject/matrix4"
+	"awesomeProject/vector3"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Voxelizer fills a Volume from the 8 aligned sprite rotations of a frame by
+// casting view rays from each rotation's camera through the grid.
+type Voxelizer struct {
+	Rotations [8]*image.Paletted
+	// Masks mark the opaque pixels of each rotation, so that every palette
+	// index is a usable color:
+	Masks   [8]*image.Alpha
+	Cameras [8]matrix4.M
+	// Order is the order the rotations are visited in:
+	Order []int
+
+	// Width and Height are the size of the aligned rotation images:
+	Width, Height int
+
+	Vol *Volume
+	// Truecolor keeps the exact colors of the average and median strategies
+	// in Vol.Truecolor instead of only their nearest palette entries:
+	Truecolor bool
+
+	horizCenter, vertCenter   float64
+	xCenter, yCenter, zCenter float64
+	radius, halfRadius        float64
+}
+
+// number of samples per voxel taken along each view ray:
+const step = 4
+
+func NewVoxelizer(vol *Volume, rotations [8]*image.Paletted, masks [8]*image.Alpha, cameras [8]matrix4.M, order []int, width, height int) *Voxelizer {
+	vz := &Voxelizer{
+		Rotations: rotations,
+		Masks:     masks,
+		Cameras:   cameras,
+		Order:     order,
+		Width:     width,
+		Height:    height,
+		Vol:       vol,
+	}
+
+	vz.horizCenter = float64(width) / 2.0
+	vz.vertCenter = float64(height) / 2.0
+
+	vz.xCenter = float64(vol.MaxX) / 2.0
+	vz.yCenter = float64(vol.MaxY) / 2.0
+	vz.zCenter = float64(vol.MaxZ) / 2.0
+
+	vz.radius = float64(width) * 2
+	vz.halfRadius = vz.radius / 2.0
+
+	return vz
+}
+
+// pixel returns the palette index of the rotation image at (u, v), with v
+// counting up from the bottom row.
+func (vz *Voxelizer) pixel(i, u, v int) uint8 {
+	return vz.Rotations[i].ColorIndexAt(u, vz.Height-1-v)
+}
+
+// opaque reports whether the rotation image has a sprite pixel at (u, v).
+func (vz *Voxelizer) opaque(i, u, v int) bool {
+	return vz.Masks[i].AlphaAt(u, vz.Height-1-v).A != 0
+}
+
+// castRay calls visit for each voxel sample along the view ray of rotation i
+// through image column u and row v, front to back. Returning true from visit
+// stops the ray.
+func (vz *Voxelizer) castRay(i, u, v int, visit func(x, y, z int) bool) {
+	vol := vz.Vol
+	for t := 0.0; t < vz.radius*step; t++ {
+		p := vector3.V{
+			X: float64(u) - vz.horizCenter,
+			Y: float64(t)*(1.0/step) - vz.halfRadius,
+			Z: float64(v) - vz.vertCenter,
+		}
+		p = vz.Cameras[i].Transform(p)
+
+		x := int(math.Round(p.X + vz.xCenter))
+		if x < 0 || x >= vol.MaxX {
+			continue
+		}
+		y := int(math.Round(p.Y + vz.yCenter))
+		if y < 0 || y >= vol.MaxY {
+			continue
+		}
+		z := int(math.Round(p.Z + vz.zCenter))
+		if z < 0 || z >= vol.MaxZ {
+			continue
+		}
+
+		if visit(x, y, z) {
+			return
+		}
+	}
+}
+
+// Fill extrudes every opaque pixel of every rotation through the whole grid.
+func (vz *Voxelizer) Fill() {
+	vol := vz.Vol
+	for _, i := range vz.Order {
+		for u := 0; u < vz.Width; u++ {
+			for v := 0; v < vz.Height; v++ {
+				if vz.opaque(i, u, v) {
+					c := vz.pixel(i, u, v)
+					vz.castRay(i, u, v, func(x, y, z int) bool {
+						vol.Filled[x][y][z] = true
+						vol.Voxels[x][y][z] = c
+						return false
+					})
+				}
+			}
+		}
+	}
+}
+
+// MarkBright makes the surface voxels seen through the opaque pixels of the
+// given masks glow; masks are aligned with Rotations and may be nil.
+func (vz *Voxelizer) MarkBright(bright [8]*image.Alpha) {
+	vol := vz.Vol
+	for _, i := range vz.Order {
+		if bright[i] == nil {
+			continue
+		}
+		for u := 0; u < vz.Width; u++ {
+			for v := 0; v < vz.Height; v++ {
+				if !vz.opaque(i, u, v) || bright[i].AlphaAt(u, vz.Height-1-v).A == 0 {
+					continue
+				}
+				vz.castRay(i, u, v, func(x, y, z int) bool {
+					if !vol.Filled[x][y][z] {
+						return false
+					}
+					vol.MarkEmissive(x, y, z)
+					return true
+				})
+			}
+		}
+	}
+}
+
+// CarveOptions controls how many views must agree before a voxel is carved
+// away.
+type CarveOptions struct {
+	// Votes is the number of views that must see through a voxel to remove
+	// it. 1 removes a voxel as soon as any view sees through it.
+	Votes int
+	// Fraction, if > 0, is used instead of Votes: a voxel is removed once
+	// the weights of the views seeing through it reach this fraction of the
+	// total weight of all views.
+	Fraction float64
+	// Weights holds a weight per rotation for Fraction; nil weighs all
+	// rotations equally.
+	Weights []float64
+}
+
+func DefaultCarveOptions() CarveOptions {
+	return CarveOptions{Votes: 1}
+}
+
+// Carve removes voxels seen through by transparent pixels, or lying outside
+// the image bounds, of enough rotations according to opts.
+func (vz *Voxelizer) Carve(opts CarveOptions) {
+	vol := vz.Vol
+	n := vol.MaxX * vol.MaxY * vol.MaxZ
+	index := func(x, y, z int) int {
+		return (x*vol.MaxY+y)*vol.MaxZ + z
+	}
+
+	weight := func(i int) float64 {
+		if opts.Weights == nil || i >= len(opts.Weights) {
+			return 1.0
+		}
+		return opts.Weights[i]
+	}
+
+	// tally up which views see through each voxel, counting each view once:
+	seen := make([]uint8, n)
+	votes := make([]float32, n)
+	for _, i := range vz.Order {
+		mark := uint8(i + 1)
+		w := float32(weight(i))
+		if opts.Fraction <= 0 {
+			w = 1
+		}
+		see := func(x, y, z int) bool {
+			k := index(x, y, z)
+			if seen[k] != mark {
+				seen[k] = mark
+				votes[k] += w
+			}
+			return false
+		}
+
+		for u := 0; u < vz.Width; u++ {
+			for v := 0; v < vz.Height; v++ {
+				if !vz.opaque(i, u, v) {
+					vz.castRay(i, u, v, see)
+				}
+			}
+		}
+
+		// wipe out anything outside the image bounds:
+		for u := vz.Width; u < int(vz.radius); u++ {
+			for v := 0; v < vz.Height; v++ {
+				vz.castRay(i, u, v, see)
+				vz.castRay(i, vz.Width-u, v, see)
+			}
+		}
+	}
+
+	threshold := float32(opts.Votes)
+	if opts.Fraction > 0 {
+		total := 0.0
+		for _, i := range vz.Order {
+			total += weight(i)
+		}
+		threshold = float32(opts.Fraction * total)
+	}
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	for x := 0; x < vol.MaxX; x++ {
+		for y := 0; y < vol.MaxY; y++ {
+			for z := 0; z < vol.MaxZ; z++ {
+				if votes[index(x, y, z)] >= threshold {
+					vol.Filled[x][y][z] = false
+				}
+			}
+		}
+	}
+}
+
+// ColorStrategy selects how the surface voxels get their color from the
+// pixels of the rotations that see them.
+type ColorStrategy int
+
+const (
+	// ColorLast paints with each rotation in turn so the last one visited wins.
+	ColorLast ColorStrategy = iota
+	// ColorNormal uses the rotation that looks most directly at the surface.
+	ColorNormal
+	// ColorAverage averages all observations in RGB, weighted by how directly
+	// each view faces the surface, and re-quantizes to the palette.
+	ColorAverage
+	// ColorMedian takes the per-channel median of all observations.
+	ColorMedian
+	// ColorMode takes the most frequently observed palette index.
+	ColorMode
+)
+
+var colorStrategyNames = map[string]ColorStrategy{
+	"last":    ColorLast,
+	"normal":  ColorNormal,
+	"average": ColorAverage,
+	"median":  ColorMedian,
+	"mode":    ColorMode,
+}
+
+func ParseColorStrategy(s string) (ColorStrategy, error) {
+	if cs, ok := colorStrategyNames[strings.ToLower(s)]; ok {
+		return cs, nil
+	}
+	return ColorLast, fmt.Errorf("unknown color strategy %q; expected last, normal, average, median or mode", s)
+}
+
+// observation is a palette index seen on a voxel from one rotation, with the
+// weight averaging gives it.
+type observation struct {
+	view int
+	c    uint8
+	w    float64
+}
+
+// Color assigns colors to the surface voxels of the carved volume using the
+// given strategy.
+func (vz *Voxelizer) Color(strategy ColorStrategy) {
+	if strategy == ColorLast {
+		vz.colorLast()
+		return
+	}
+
+	vol := vz.Vol
+	pal := vz.Rotations[0].Palette
+
+	// collect what each view sees on the first solid voxel along each ray:
+	observations := make(map[[3]int][]observation)
+	for _, i := range vz.Order {
+		for u := 0; u < vz.Width; u++ {
+			for v := 0; v < vz.Height; v++ {
+				if !vz.opaque(i, u, v) {
+					continue
+				}
+				c := vz.pixel(i, u, v)
+				vz.castRay(i, u, v, func(x, y, z int) bool {
+					if !vol.Filled[x][y][z] {
+						return false
+					}
+					p := [3]int{x, y, z}
+					observations[p] = append(observations[p], observation{view: i, c: c})
+					return true
+				})
+			}
+		}
+	}
+
+	for p, obs := range observations {
+		var c uint8
+		switch strategy {
+		case ColorNormal:
+			c = vz.mostAligned(p, obs)
+		case ColorAverage, ColorMedian:
+			var rgba color.RGBA
+			if strategy == ColorMedian {
+				rgba = medianRGBA(pal, obs)
+			} else {
+				rgba = averageRGBA(pal, vz.weigh(p, obs))
+			}
+			c = uint8(pal.Index(rgba))
+			if vz.Truecolor {
+				vol.SetTruecolor(p[0], p[1], p[2], rgba)
+			}
+		default:
+			c = modeColor(obs)
+		}
+		vol.Voxels[p[0]][p[1]][p[2]] = c
+	}
+}
+
+// surfaceNormal estimates the outward normal at a voxel from the directions
+// of its empty neighbors.
+func (vz *Voxelizer) surfaceNormal(p [3]int) vector3.V {
+	n := vector3.V{}
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dz := -1; dz <= 1; dz++ {
+				if dx == 0 && dy == 0 && dz == 0 {
+					continue
+				}
+				if !vz.Vol.IsFilled(p[0]+dx, p[1]+dy, p[2]+dz) {
+					n = n.Add(vector3.V{X: float64(dx), Y: float64(dy), Z: float64(dz)})
+				}
+			}
+		}
+	}
+	return n
+}
+
+// mostAligned picks the observations from the view facing the surface most
+// directly, falling back to the mode when the normal is undefined.
+func (vz *Voxelizer) mostAligned(p [3]int, obs []observation) uint8 {
+	n := vz.surfaceNormal(p)
+	if n.Dot(n) == 0 {
+		return modeColor(obs)
+	}
+	n = n.Normalize()
+
+	best := -1
+	bestDot := math.Inf(-1)
+	for _, o := range obs {
+		if d := vz.alignment(o.view, n); d > bestDot {
+			bestDot = d
+			best = o.view
+		}
+	}
+
+	var aligned []observation
+	for _, o := range obs {
+		if o.view == best {
+			aligned = append(aligned, o)
+		}
+	}
+	return modeColor(aligned)
+}
+
+// alignment is the cosine between the surface normal n and the direction
+// back towards the camera of view.
+func (vz *Voxelizer) alignment(view int, n vector3.V) float64 {
+	// cameras look along +Y in view space:
+	forward := vz.Cameras[view].Transform(vector3.V{X: 0, Y: 1, Z: 0})
+	return -forward.Dot(n)
+}
+
+// weigh sets the weight of each observation to how directly its view faces
+// the surface at p, clamped to zero for views from behind. Where the normal
+// is undefined all views weigh the same.
+func (vz *Voxelizer) weigh(p [3]int, obs []observation) []observation {
+	n := vz.surfaceNormal(p)
+	weighed := make([]observation, len(obs))
+	for i, o := range obs {
+		o.w = 1
+		if n.Dot(n) != 0 {
+			o.w = math.Max(0, vz.alignment(o.view, n.Normalize()))
+		}
+		weighed[i] = o
+	}
+	return weighed
+}
+
+// averageRGBA averages the observations in RGB by their weights, or evenly
+// if all weights are zero.
+func averageRGBA(pal color.Palette, obs []observation) color.RGBA {
+	var r, g, b, total float64
+	for _, o := range obs {
+		total += o.w
+	}
+	for _, o := range obs {
+		w := o.w
+		if total == 0 {
+			w = 1
+		}
+		cr, cg, cb, _ := pal[o.c].RGBA()
+		r += w * float64(cr>>8)
+		g += w * float64(cg>>8)
+		b += w * float64(cb>>8)
+	}
+	if total == 0 {
+		total = float64(len(obs))
+	}
+	return color.RGBA{
+		R: uint8(math.Round(r / total)),
+		G: uint8(math.Round(g / total)),
+		B: uint8(math.Round(b / total)),
+		A: 0xFF,
+	}
+}
+
+// medianRGBA takes the per-channel median of all observations.
+func medianRGBA(pal color.Palette, obs []observation) color.RGBA {
+	rs := make([]int, 0, len(obs))
+	gs := make([]int, 0, len(obs))
+	bs := make([]int, 0, len(obs))
+	for _, o := range obs {
+		cr, cg, cb, _ := pal[o.c].RGBA()
+		rs = append(rs, int(cr>>8))
+		gs = append(gs, int(cg>>8))
+		bs = append(bs, int(cb>>8))
+	}
+	sort.Ints(rs)
+	sort.Ints(gs)
+	sort.Ints(bs)
+
+	m := len(obs) / 2
+	return color.RGBA{
+		R: uint8(rs[m]),
+		G: uint8(gs[m]),
+		B: uint8(bs[m]),
+		A: 0xFF,
+	}
+}
+
+// modeColor returns the most frequent index; ties go to the first seen.
+func modeColor(obs []observation) uint8 {
+	var counts [256]int
+	best := obs[0].c
+	for _, o := range obs {
+		counts[o.c]++
+		if counts[o.c] > counts[best] {
+			best = o.c
+		}
+	}
+	return best
+}
+
+// colorLast repaints the surfaces of the carved volume from each rotation.
+func (vz *Voxelizer) colorLast() {
+	vol := vz.Vol
+	for _, i := range vz.Order {
+		for u := 0; u < vz.Width; u++ {
+			for v := 0; v < vz.Height; v++ {
+				if vz.opaque(i, u, v) {
+					c := vz.pixel(i, u, v)
+					depth := 0
+					vz.castRay(i, u, v, func(x, y, z int) bool {
+						vol.Voxels[x][y][z] = c
+
+						// only color the surface:
+						if vol.Filled[x][y][z] {
+							depth++
+							if depth > 3 {
+								return true
+							}
+						}
+						return false
+					})
+				}
+			}
+		}
+	}
+}
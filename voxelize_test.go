@@ -0,0 +1,127 @@
+package main
+
+import (
+	"awesomeProject/matrix4"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// testVoxelizer returns a voxelizer for a full 4x4x4 volume seen by two
+// 4x4 views: view 0 looks along +Y, so its column u shows x = u, and view 1
+// is turned by 90 degrees, so its column u shows y = u. Column 2 of each
+// view is transparent.
+func testVoxelizer() *Voxelizer {
+	vol := NewVolume(4, 4, 4)
+	for x := range vol.Filled {
+		for y := range vol.Filled[x] {
+			for z := range vol.Filled[x][y] {
+				vol.Filled[x][y][z] = true
+			}
+		}
+	}
+
+	var rotations [8]*image.Paletted
+	var masks [8]*image.Alpha
+	var cameras [8]matrix4.M
+	for i := 0; i < 2; i++ {
+		rotations[i] = image.NewPaletted(image.Rect(0, 0, 4, 4), testPalette())
+		masks[i] = image.NewAlpha(rotations[i].Rect)
+		for u := 0; u < 4; u++ {
+			for v := 0; v < 4; v++ {
+				if u != 2 {
+					masks[i].Pix[masks[i].PixOffset(u, v)] = 0xFF
+				}
+			}
+		}
+		cameras[i] = matrix4.RotationZ(float64(i) * math.Pi / 2)
+	}
+	return NewVoxelizer(vol, rotations, masks, cameras, []int{0, 1}, 4, 4)
+}
+
+func TestCarve(t *testing.T) {
+	// (2,2) is seen through by both views, (2,3) by view 0 only, (3,2) by
+	// view 1 only and (3,3) by neither:
+	cells := [][2]int{{2, 2}, {2, 3}, {3, 2}, {3, 3}}
+	tests := []struct {
+		name string
+		opts CarveOptions
+		// whether each of cells survives:
+		want [4]bool
+	}{
+		{"one vote", CarveOptions{Votes: 1}, [4]bool{false, false, false, true}},
+		// each view votes once however many samples of its rays fall
+		// into a voxel:
+		{"two votes", CarveOptions{Votes: 2}, [4]bool{false, true, true, true}},
+		{"three votes", CarveOptions{Votes: 3}, [4]bool{true, true, true, true}},
+		{"fraction", CarveOptions{Fraction: 0.75}, [4]bool{false, true, true, true}},
+		{"half", CarveOptions{Fraction: 0.5}, [4]bool{false, false, false, true}},
+		// view 1 weighs 3 of 4, enough on its own; view 0 is not:
+		{"weighted", CarveOptions{Fraction: 0.5, Weights: []float64{1, 3}}, [4]bool{false, true, false, true}},
+	}
+	for _, tt := range tests {
+		vz := testVoxelizer()
+		vz.Carve(tt.opts)
+		for k, c := range cells {
+			for z := 0; z < 4; z++ {
+				if got := vz.Vol.Filled[c[0]][c[1]][z]; got != tt.want[k] {
+					t.Errorf("%s: voxel %d,%d,%d filled = %v, want %v", tt.name, c[0], c[1], z, got, tt.want[k])
+				}
+			}
+		}
+	}
+}
+
+func TestColorStrategies(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{A: 0xFF},
+		color.RGBA{R: 255, A: 0xFF},
+		color.RGBA{G: 255, A: 0xFF},
+		color.RGBA{B: 255, A: 0xFF},
+		color.RGBA{R: 100, G: 100, B: 100, A: 0xFF},
+	}
+	// the voxel at (1,0,1) faces -Y, straight at view 0 and sideways to
+	// view 1:
+	vz := testVoxelizer()
+	p := [3]int{1, 0, 1}
+	obs := []observation{
+		{view: 0, c: 1}, {view: 0, c: 1}, {view: 0, c: 4},
+		{view: 1, c: 2}, {view: 1, c: 2}, {view: 1, c: 2}, {view: 1, c: 3},
+	}
+
+	weighed := vz.weigh(p, obs)
+	for _, o := range weighed {
+		if want := float64(1 - o.view); math.Abs(o.w-want) > 1e-9 {
+			t.Errorf("view %d weighs %g, want %g", o.view, o.w, want)
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  color.RGBA
+		want color.RGBA
+	}{
+		{"average", averageRGBA(pal, weighed), color.RGBA{R: 203, G: 33, B: 33, A: 0xFF}},
+		// without weights every observation counts the same:
+		{"unweighted average", averageRGBA(pal, obs), color.RGBA{R: 87, G: 124, B: 51, A: 0xFF}},
+		{"median", medianRGBA(pal, obs), color.RGBA{G: 100, A: 0xFF}},
+		{"aligned", rgbaOf(pal[vz.mostAligned(p, obs)]), rgbaOf(pal[1])},
+		{"mode", rgbaOf(pal[modeColor(obs)]), rgbaOf(pal[2])},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// inside the volume the normal is undefined, so every view counts:
+	for _, o := range vz.weigh([3]int{1, 1, 1}, obs) {
+		if o.w != 1 {
+			t.Errorf("view %d weighs %g inside the volume", o.view, o.w)
+		}
+	}
+	if c := vz.mostAligned([3]int{1, 1, 1}, obs); c != 2 {
+		t.Errorf("aligned inside the volume = %d, want the mode 2", c)
+	}
+}
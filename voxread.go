@@ -0,0 +1,496 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// magicaVoxelDefaultPalette is the palette MagicaVoxel uses for files without
+// an RGBA chunk, indexed by VOX color index: a 6x6x6 color cube without
+// black, followed by ramps of red, green, blue and gray.
+var magicaVoxelDefaultPalette = func() (pal [256]color.RGBA) {
+	levels := []uint8{0xff, 0xcc, 0x99, 0x66, 0x33, 0x00}
+	i := 1
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				if r == 0 && g == 0 && b == 0 {
+					continue
+				}
+				pal[i] = color.RGBA{R: r, G: g, B: b, A: 0xff}
+				i++
+			}
+		}
+	}
+
+	ramp := []uint8{0xee, 0xdd, 0xbb, 0xaa, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+	for _, v := range ramp {
+		pal[i] = color.RGBA{R: v, A: 0xff}
+		i++
+	}
+	for _, v := range ramp {
+		pal[i] = color.RGBA{G: v, A: 0xff}
+		i++
+	}
+	for _, v := range ramp {
+		pal[i] = color.RGBA{B: v, A: 0xff}
+		i++
+	}
+	for _, v := range ramp {
+		pal[i] = color.RGBA{R: v, G: v, B: v, A: 0xff}
+		i++
+	}
+	return
+}()
+
+// VoxFile is the parsed content of a MagicaVoxel file.
+type VoxFile struct {
+	Models []VoxModelData
+	// Palette is indexed by VOX color index; entry 0 is empty space.
+	Palette [256]color.RGBA
+	// Materials holds the MATL attributes of each VOX color index, if any.
+	Materials map[int]map[string]string
+
+	Transforms map[int]*VoxTransform
+	Groups     map[int]*VoxGroup
+	Shapes     map[int]*VoxShape
+	Layers     map[int]*VoxLayer
+}
+
+// VoxModelData is one SIZE/XYZI pair.
+type VoxModelData struct {
+	Size [3]int
+	// Voxels holds x, y, z, color index quadruples.
+	Voxels []byte
+}
+
+type VoxTransform struct {
+	Attrs  map[string]string
+	Child  int
+	Layer  int
+	Frames []map[string]string
+}
+
+type VoxGroup struct {
+	Attrs    map[string]string
+	Children []int
+}
+
+type VoxShape struct {
+	Attrs  map[string]string
+	Models []int
+	// ModelAttrs holds the attributes of each model, e.g. its "_f" frame.
+	ModelAttrs []map[string]string
+}
+
+type VoxLayer struct {
+	Attrs map[string]string
+}
+
+// voxReader reads little-endian values from a chunk, remembering the first
+// error.
+type voxReader struct {
+	b   []byte
+	err error
+}
+
+func (r *voxReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *voxReader) int32() int {
+	if len(r.b) < 4 {
+		r.fail("unexpected end of chunk")
+		r.b = nil
+		return 0
+	}
+	v := int32(binary.LittleEndian.Uint32(r.b))
+	r.b = r.b[4:]
+	return int(v)
+}
+
+func (r *voxReader) bytes(n int) []byte {
+	if n < 0 || len(r.b) < n {
+		r.fail("unexpected end of chunk")
+		r.b = nil
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *voxReader) string() string {
+	return string(r.bytes(r.int32()))
+}
+
+func (r *voxReader) dict() map[string]string {
+	n := r.int32()
+	d := make(map[string]string, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.string()
+		d[k] = r.string()
+	}
+	return d
+}
+
+// ParseVox parses MagicaVoxel data, skipping chunks it does not understand.
+func ParseVox(b []byte) (vf *VoxFile, err error) {
+	if len(b) < 20 || string(b[0:4]) != "VOX " {
+		return nil, fmt.Errorf("not a VOX file")
+	}
+	if string(b[8:12]) != "MAIN" {
+		return nil, fmt.Errorf("missing MAIN chunk")
+	}
+
+	vf = &VoxFile{
+		Palette:    magicaVoxelDefaultPalette,
+		Transforms: make(map[int]*VoxTransform),
+		Groups:     make(map[int]*VoxGroup),
+		Shapes:     make(map[int]*VoxShape),
+		Layers:     make(map[int]*VoxLayer),
+		Materials:  make(map[int]map[string]string),
+	}
+
+	var size *[3]int
+	for o := 20; o < len(b); {
+		if o+12 > len(b) {
+			return nil, fmt.Errorf("truncated chunk header at offset %d", o)
+		}
+		id := string(b[o : o+4])
+		n := int(binary.LittleEndian.Uint32(b[o+4 : o+8]))
+		if o+12+n > len(b) {
+			return nil, fmt.Errorf("%s chunk at offset %d runs past the end of the file", id, o)
+		}
+		r := &voxReader{b: b[o+12 : o+12+n]}
+		// children are stored inline after the content, so just keep going:
+		o += 12 + n
+
+		switch id {
+		case "SIZE":
+			size = &[3]int{r.int32(), r.int32(), r.int32()}
+		case "XYZI":
+			if size == nil {
+				return nil, fmt.Errorf("XYZI chunk without a SIZE chunk")
+			}
+			count := r.int32()
+			vf.Models = append(vf.Models, VoxModelData{Size: *size, Voxels: r.bytes(count * 4)})
+			size = nil
+		case "RGBA":
+			for i := 1; i < 256; i++ {
+				c := r.bytes(4)
+				if c == nil {
+					break
+				}
+				vf.Palette[i] = color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xff}
+			}
+		case "nTRN":
+			id := r.int32()
+			t := &VoxTransform{Attrs: r.dict(), Child: r.int32()}
+			// reserved id:
+			r.int32()
+			t.Layer = r.int32()
+			frames := r.int32()
+			for i := 0; i < frames && r.err == nil; i++ {
+				t.Frames = append(t.Frames, r.dict())
+			}
+			vf.Transforms[id] = t
+		case "nGRP":
+			id := r.int32()
+			g := &VoxGroup{Attrs: r.dict()}
+			children := r.int32()
+			for i := 0; i < children && r.err == nil; i++ {
+				g.Children = append(g.Children, r.int32())
+			}
+			vf.Groups[id] = g
+		case "nSHP":
+			id := r.int32()
+			s := &VoxShape{Attrs: r.dict()}
+			models := r.int32()
+			for i := 0; i < models && r.err == nil; i++ {
+				s.Models = append(s.Models, r.int32())
+				s.ModelAttrs = append(s.ModelAttrs, r.dict())
+			}
+			vf.Shapes[id] = s
+		case "LAYR":
+			id := r.int32()
+			vf.Layers[id] = &VoxLayer{Attrs: r.dict()}
+		case "MATL":
+			id := r.int32()
+			vf.Materials[id] = r.dict()
+		}
+
+		if r.err != nil {
+			return nil, fmt.Errorf("%s chunk: %w", id, r.err)
+		}
+	}
+
+	return
+}
+
+// voxRotation decodes the packed rotation byte of an nTRN frame "_r"
+// attribute into a row-major 3x3 matrix.
+func voxRotation(packed int) (m [3][3]int) {
+	first := packed & 3
+	second := (packed >> 2) & 3
+	third := 3 - first - second
+	rows := [3]int{first, second, third}
+	for i, col := range rows {
+		if col < 0 || col > 2 {
+			return [3][3]int{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+		}
+		m[i][col] = 1
+		if packed&(1<<(4+i)) != 0 {
+			m[i][col] = -1
+		}
+	}
+	return
+}
+
+// voxFrameAttrs returns the keyframe attributes in effect at frame: the last
+// one whose "_f" is not after it.
+func voxFrameAttrs(frames []map[string]string, frame int) map[string]string {
+	var best map[string]string
+	bestF := -1
+	for i, f := range frames {
+		n := i
+		if s, ok := f["_f"]; ok {
+			n, _ = strconv.Atoi(s)
+		}
+		if n <= frame && n >= bestF {
+			best, bestF = f, n
+		}
+	}
+	if best == nil && len(frames) > 0 {
+		best = frames[0]
+	}
+	return best
+}
+
+// voxPlacement is a model positioned in the scene.
+type voxPlacement struct {
+	model int
+	rot   [3][3]int
+	trans [3]int
+	// layer is the layer of the nearest transform node above the model:
+	layer int
+}
+
+// place walks the scene graph and returns where each visible model sits at
+// the given animation frame. Files without a scene graph place every model at
+// the origin.
+func (vf *VoxFile) place(frame int) (placements []voxPlacement) {
+	identity := [3][3]int{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+	if len(vf.Transforms) == 0 {
+		for i := range vf.Models {
+			placements = append(placements, voxPlacement{model: i, rot: identity})
+		}
+		return
+	}
+
+	hidden := func(layer int) bool {
+		l, ok := vf.Layers[layer]
+		return ok && l.Attrs["_hidden"] == "1"
+	}
+
+	var walk func(id int, rot [3][3]int, trans [3]int, layer, depth int)
+	walk = func(id int, rot [3][3]int, trans [3]int, layer, depth int) {
+		if depth > 64 {
+			return
+		}
+		if t, ok := vf.Transforms[id]; ok {
+			if t.Attrs["_hidden"] == "1" || hidden(t.Layer) {
+				return
+			}
+			local := identity
+			var offset [3]int
+			if attrs := voxFrameAttrs(t.Frames, frame); attrs != nil {
+				if s, ok := attrs["_r"]; ok {
+					if packed, err := strconv.Atoi(s); err == nil {
+						local = voxRotation(packed)
+					}
+				}
+				if s, ok := attrs["_t"]; ok {
+					fields := strings.Fields(s)
+					for a := 0; a < 3 && a < len(fields); a++ {
+						offset[a], _ = strconv.Atoi(fields[a])
+					}
+				}
+			}
+
+			// parent transform applied after the local one:
+			var r [3][3]int
+			var tr [3]int
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					for k := 0; k < 3; k++ {
+						r[i][j] += rot[i][k] * local[k][j]
+					}
+					tr[i] += rot[i][j] * offset[j]
+				}
+				tr[i] += trans[i]
+			}
+			if t.Layer >= 0 {
+				layer = t.Layer
+			}
+			walk(t.Child, r, tr, layer, depth+1)
+			return
+		}
+		if g, ok := vf.Groups[id]; ok {
+			for _, child := range g.Children {
+				walk(child, rot, trans, layer, depth+1)
+			}
+			return
+		}
+		if s, ok := vf.Shapes[id]; ok {
+			// animated shapes hold one model per keyframe:
+			model := -1
+			bestF := -1
+			for i, m := range s.Models {
+				n := 0
+				if v, ok := s.ModelAttrs[i]["_f"]; ok {
+					n, _ = strconv.Atoi(v)
+				}
+				if n <= frame && n > bestF {
+					model, bestF = m, n
+				}
+			}
+			if model < 0 && len(s.Models) > 0 {
+				model = s.Models[0]
+			}
+			if model >= 0 && model < len(vf.Models) {
+				placements = append(placements, voxPlacement{model: model, rot: rot, trans: trans, layer: layer})
+			}
+		}
+	}
+	walk(0, identity, [3]int{}, 0, 0)
+
+	return
+}
+
+// ToVolume assembles the models visible at the given animation frame into a
+// Volume, mapping VOX colors onto the nearest colors of pal. Colors pal does
+// not hold exactly are kept as truecolor as well. The scene origin becomes
+// the Volume's pivot.
+func (vf *VoxFile) ToVolume(pal color.Palette, frame int) (vol *Volume, err error) {
+	placements := vf.place(frame)
+
+	// map VOX colors to palette indices, preferring our own index+1 layout:
+	var index [256]uint8
+	for c := 1; c < 256; c++ {
+		want := vf.Palette[c]
+		if c-1 < len(pal) && rgbaOf(pal[c-1]) == want {
+			index[c] = uint8(c - 1)
+		} else {
+			index[c] = uint8(pal.Index(want))
+		}
+	}
+
+	type voxel struct {
+		p         [3]int
+		c         uint8
+		vc        uint8
+		glow      bool
+		translate bool
+	}
+	var voxels []voxel
+	lo := [3]int{math.MaxInt32, math.MaxInt32, math.MaxInt32}
+	hi := [3]int{math.MinInt32, math.MinInt32, math.MinInt32}
+
+	for _, pl := range placements {
+		m := vf.Models[pl.model]
+		// voxels on the translation layer bring their colors into the range:
+		l, ok := vf.Layers[pl.layer]
+		translate := ok && l.Attrs["_name"] == voxLayerNames[voxLayerTranslation]
+		for i := 0; i+3 < len(m.Voxels); i += 4 {
+			if m.Voxels[i+3] == 0 {
+				continue
+			}
+			// models are placed by their center:
+			local := [3]int{
+				int(m.Voxels[i+0]) - m.Size[0]/2,
+				int(m.Voxels[i+1]) - m.Size[1]/2,
+				int(m.Voxels[i+2]) - m.Size[2]/2,
+			}
+			var p [3]int
+			for a := 0; a < 3; a++ {
+				p[a] = pl.trans[a]
+				for b := 0; b < 3; b++ {
+					p[a] += pl.rot[a][b] * local[b]
+				}
+				if p[a] < lo[a] {
+					lo[a] = p[a]
+				}
+				if p[a] > hi[a] {
+					hi[a] = p[a]
+				}
+			}
+			c := m.Voxels[i+3]
+			voxels = append(voxels, voxel{p: p, c: index[c], vc: c, glow: vf.Materials[int(c)]["_type"] == "_emit", translate: translate})
+		}
+	}
+
+	if len(voxels) == 0 {
+		return nil, fmt.Errorf("no visible voxels at frame %d", frame)
+	}
+
+	// keep the origin inside the grid so the pivot stays meaningful:
+	for a := 0; a < 3; a++ {
+		if lo[a] > 0 {
+			lo[a] = 0
+		}
+		if hi[a] < 0 {
+			hi[a] = 0
+		}
+	}
+
+	vol = NewVolume(hi[0]-lo[0]+1, hi[1]-lo[1]+1, hi[2]-lo[2]+1)
+	for _, v := range voxels {
+		x, y, z := v.p[0]-lo[0], v.p[1]-lo[1], v.p[2]-lo[2]
+		vol.Filled[x][y][z] = true
+		vol.Voxels[x][y][z] = v.c
+		if want := vf.Palette[v.vc]; rgbaOf(pal[v.c]) != want {
+			vol.SetTruecolor(x, y, z, want)
+		}
+		if v.glow {
+			vol.MarkEmissive(x, y, z)
+		}
+		if v.translate {
+			vol.Translation[v.c] = true
+		}
+	}
+
+	// the origin is the center of a voxel column, at the bottom of its layer:
+	vol.Pivot.X = float64(-lo[0]) + 0.5
+	vol.Pivot.Y = float64(-lo[1]) + 0.5
+	vol.Pivot.Z = float64(-lo[2])
+
+	return
+}
+
+// loadVoxel reads a MagicaVoxel file into a Volume with colors from pal.
+func loadVoxel(voxPath string, pal color.Palette, frame int) (vol *Volume, err error) {
+	b, err := os.ReadFile(voxPath)
+	if err != nil {
+		return
+	}
+
+	vf, err := ParseVox(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", voxPath, err)
+	}
+
+	vol, err = vf.ToVolume(pal, frame)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", voxPath, err)
+	}
+	return
+}
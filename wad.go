@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -156,3 +157,80 @@ func (wc *WADCollection) IterateLumpsBetween(start string, end string, iter func
 		}
 	}
 }
+
+// spriteMarkers are the start and end markers of the sprite namespace; PWADs
+// may use the doubled names, and often pair SS_START with S_END.
+var spriteMarkers = [][2]string{{"S_START", "S_END"}, {"SS_START", "SS_END"}}
+
+// IterateSprites calls iter for the lumps of each WAD's sprite namespace,
+// markers included, until iter returns true. WADs without sprites are
+// skipped.
+func (wc *WADCollection) IterateSprites(iter func(*Lump) bool) {
+	for _, wad := range wc.Ordered {
+		if wad.IterateSprites(iter) {
+			return
+		}
+	}
+}
+
+// IterateSprites calls iter for the lumps of the WAD's sprite namespace,
+// markers included, and tells whether iter returned true to stop. The
+// namespace runs from the first start marker to the first end marker after
+// it, or to the end of the WAD.
+func (wad *WAD) IterateSprites(iter func(*Lump) bool) bool {
+	start, found := uint32(0), false
+	for _, m := range spriteMarkers {
+		if i, ok := wad.LumpByName[m[0]]; ok && (!found || i < start) {
+			start, found = i, true
+		}
+	}
+	if !found {
+		return false
+	}
+	end := wad.EndIndex()
+	for _, m := range spriteMarkers {
+		if i, ok := wad.LumpByName[m[1]]; ok && i > start && i < end {
+			end = i
+		}
+	}
+	return wad.IterateLumpsBetween(start, end, iter)
+}
+
+// WriteWAD writes lumps out as a new WAD file. identification is either
+// "IWAD" or "PWAD".
+func WriteWAD(path string, identification string, lumps []Lump) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return EncodeWAD(w, identification, lumps)
+	})
+}
+
+// EncodeWAD writes a WAD made of lumps to w; the header is followed by the
+// lump data, then the directory.
+func EncodeWAD(w io.Writer, identification string, lumps []Lump) error {
+	file := &errWriter{w: w}
+
+	directoryOffs := uint32(12)
+	for i := range lumps {
+		directoryOffs += uint32(len(lumps[i].Data))
+	}
+
+	_, _ = io.WriteString(file, identification)
+	_ = binary.Write(file, le, uint32(len(lumps)))
+	_ = binary.Write(file, le, directoryOffs)
+
+	for i := range lumps {
+		_, _ = file.Write(lumps[i].Data)
+	}
+
+	offset := uint32(12)
+	for i := range lumps {
+		_ = binary.Write(file, le, offset)
+		_ = binary.Write(file, le, uint32(len(lumps[i].Data)))
+		name := [8]byte{}
+		copy(name[:], lumps[i].Name)
+		_, _ = file.Write(name[:])
+		offset += uint32(len(lumps[i].Data))
+	}
+
+	return file.err
+}
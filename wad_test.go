@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIterateSprites(t *testing.T) {
+	tests := []struct {
+		lumps []string
+		want  []string
+	}{
+		{
+			[]string{"PLAYPAL", "S_START", "TROOA1", "TROOB1", "S_END", "F_START", "FLAT1", "F_END"},
+			[]string{"S_START", "TROOA1", "TROOB1", "S_END"},
+		},
+		{
+			[]string{"SS_START", "POSSA1", "SS_END", "DEHACKED"},
+			[]string{"SS_START", "POSSA1", "SS_END"},
+		},
+		{
+			[]string{"SS_START", "POSSA1", "POSSB1", "S_END", "PP_START", "WALL1", "PP_END"},
+			[]string{"SS_START", "POSSA1", "POSSB1", "S_END"},
+		},
+		{
+			[]string{"S_START", "SARGA1", "SS_END", "DECORATE"},
+			[]string{"S_START", "SARGA1", "SS_END"},
+		},
+		{
+			[]string{"S_START", "CYBRA1"},
+			[]string{"S_START", "CYBRA1"},
+		},
+		{
+			[]string{"PLAYPAL", "TROOA1"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		wc := &WADCollection{Ordered: []*WAD{testWAD("test.wad", tt.lumps...)}}
+		var got []string
+		wc.IterateSprites(func(lump *Lump) bool {
+			got = append(got, lump.Name)
+			return false
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.lumps, got, tt.want)
+		}
+	}
+}
+
+func TestIterateSpritesStops(t *testing.T) {
+	wc := &WADCollection{Ordered: []*WAD{
+		testWAD("pwad.wad", "SS_START", "TROOA1", "S_END"),
+		testWAD("iwad.wad", "S_START", "TROOA1", "S_END"),
+	}}
+	var got []string
+	wc.IterateSprites(func(lump *Lump) bool {
+		got = append(got, lump.Name)
+		return lump.Name == "TROOA1"
+	})
+	if want := []string{"SS_START", "TROOA1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}